@@ -0,0 +1,258 @@
+package bytedance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Grant types of v1/oauth/token.
+const (
+	GrantTypeAuthorizationCode = "app_to_tp_authorization_code"
+	GrantTypeRefreshToken      = "app_to_tp_refresh_token"
+)
+
+// cachedAuthorizerToken is an authorizer token held in memory, unsaved is set
+// while it has not been written to the TokenStore yet.
+type cachedAuthorizerToken struct {
+	token   AuthorizerToken
+	unsaved bool
+}
+
+// AuthorizerTokenManager manages authorizer_access_token of authorized micro apps.
+// Refreshes of one authorizer appid are serialized, and the rotated
+// authorizer_refresh_token is saved to the TokenStore before the new
+// authorizer_access_token is returned, since each refresh token can be used
+// only once.
+type AuthorizerTokenManager struct {
+	client         *Client
+	componentAppID string
+	componentToken TokenSource
+	store          TokenStore
+
+	// RefreshMargin is how long before expiry the token is refreshed.
+	// Zero means defaultRefreshMargin.
+	RefreshMargin time.Duration
+
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+	cache map[string]*cachedAuthorizerToken
+}
+
+// NewAuthorizerTokenManager returns an AuthorizerTokenManager for the authorizers
+// of componentAppID. Tokens are persisted to the TokenStore of c, or kept in
+// memory if c has none.
+func NewAuthorizerTokenManager(c *Client, componentAppID string, componentToken TokenSource) *AuthorizerTokenManager {
+	store := c.TokenStore
+	if store == nil {
+		store = NewMemoryTokenStore()
+	}
+	return &AuthorizerTokenManager{
+		client:         c,
+		componentAppID: componentAppID,
+		componentToken: componentToken,
+		store:          store,
+		locks:          make(map[string]chan struct{}),
+		cache:          make(map[string]*cachedAuthorizerToken),
+	}
+}
+
+// Authorize exchanges authorizationCode for the tokens of the authorizer and saves them.
+func (m *AuthorizerTokenManager) Authorize(ctx context.Context, authorizationCode string) (*OAuthToken, error) {
+	componentAccessToken, err := m.componentToken.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	oAuthToken, _, err := m.client.ThirdParty.GetOAuthToken(ctx, m.componentAppID, componentAccessToken,
+		authorizationCode, GrantTypeAuthorizationCode)
+	if oAuthToken == nil {
+		return nil, err
+	}
+
+	appID := oAuthToken.AuthorizerAppID
+	if err := m.lock(ctx, appID); err != nil {
+		return nil, err
+	}
+	defer m.unlock(appID)
+	token := AuthorizerToken{
+		AccessToken:  oAuthToken.AuthorizerAccessToken,
+		RefreshToken: oAuthToken.AuthorizerRefreshToken,
+		ExpiresAt:    start.Add(time.Duration(oAuthToken.ExpiresIn) * time.Second),
+	}
+	if err := m.save(ctx, appID, token); err != nil {
+		return nil, err
+	}
+	return oAuthToken, nil
+}
+
+// Token returns a valid authorizer_access_token of authorizerAppID, refreshing it if necessary.
+func (m *AuthorizerTokenManager) Token(ctx context.Context, authorizerAppID string) (string, error) {
+	return m.token(ctx, authorizerAppID, func(current *AuthorizerToken) bool {
+		return !current.Valid(m.margin())
+	})
+}
+
+// Refresh rotates the tokens of authorizerAppID regardless of the current one.
+func (m *AuthorizerTokenManager) Refresh(ctx context.Context, authorizerAppID string) (string, error) {
+	return m.token(ctx, authorizerAppID, func(*AuthorizerToken) bool {
+		return true
+	})
+}
+
+// Renew rotates the tokens of authorizerAppID if stale, an access token rejected
+// by the server, is still the current one. Otherwise the current one is returned,
+// so that concurrent renewals of the same stale token rotate only once.
+func (m *AuthorizerTokenManager) Renew(ctx context.Context, authorizerAppID, stale string) (string, error) {
+	return m.token(ctx, authorizerAppID, func(current *AuthorizerToken) bool {
+		return current.AccessToken == stale || !current.Valid(m.margin())
+	})
+}
+
+// AuthorizerAppID returns the authorizer appid which accessToken was issued to
+// through m, or false if it's unknown.
+func (m *AuthorizerTokenManager) AuthorizerAppID(accessToken string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for appID, cached := range m.cache {
+		if cached.token.AccessToken == accessToken {
+			return appID, true
+		}
+	}
+	return "", false
+}
+
+// TokenSource returns a TokenSource of authorizerAppID backed by m.
+func (m *AuthorizerTokenManager) TokenSource(authorizerAppID string) TokenSource {
+	return authorizerTokenSource{m: m, authorizerAppID: authorizerAppID}
+}
+
+// token returns the current token of authorizerAppID, or rotates it if needRefresh reports so.
+func (m *AuthorizerTokenManager) token(ctx context.Context, authorizerAppID string,
+	needRefresh func(current *AuthorizerToken) bool) (string, error) {
+	if err := m.lock(ctx, authorizerAppID); err != nil {
+		return "", err
+	}
+	defer m.unlock(authorizerAppID)
+
+	current, err := m.load(ctx, authorizerAppID)
+	if err != nil {
+		return "", err
+	}
+	if !needRefresh(current) {
+		return current.AccessToken, nil
+	}
+
+	componentAccessToken, err := m.componentToken.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	v, _, err := m.client.ThirdParty.RefreshOAuthToken(ctx, m.componentAppID, componentAccessToken,
+		current.RefreshToken, GrantTypeRefreshToken)
+	if err != nil {
+		return "", err
+	}
+	token := AuthorizerToken{
+		AccessToken:  v.AuthorizerAccessToken,
+		RefreshToken: v.AuthorizerRefreshToken,
+		ExpiresAt:    start.Add(time.Duration(v.ExpiresIn) * time.Second),
+	}
+	if err := m.save(ctx, authorizerAppID, token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// load returns the latest token of authorizerAppID, the lock of it must be held.
+// The store is read every time to pick up tokens rotated by other processes, a
+// token which failed to be saved is saved again first.
+func (m *AuthorizerTokenManager) load(ctx context.Context, authorizerAppID string) (*AuthorizerToken, error) {
+	m.mu.Lock()
+	cached := m.cache[authorizerAppID]
+	m.mu.Unlock()
+
+	if cached != nil && cached.unsaved {
+		if err := m.save(ctx, authorizerAppID, cached.token); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := m.store.GetAuthorizerToken(ctx, m.componentAppID, authorizerAppID)
+	if err != nil && err != ErrTokenNotFound {
+		return nil, err
+	}
+	if cached != nil && (stored == nil || cached.token.ExpiresAt.After(stored.ExpiresAt)) {
+		token := cached.token
+		return &token, nil
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("authorizer %v: %w", authorizerAppID, ErrTokenNotFound)
+	}
+
+	m.mu.Lock()
+	m.cache[authorizerAppID] = &cachedAuthorizerToken{token: *stored}
+	m.mu.Unlock()
+	return stored, nil
+}
+
+// save keeps token in memory and writes it to the store. On failure the token
+// stays in memory and is marked unsaved, so that the rotated refresh token is
+// not lost.
+func (m *AuthorizerTokenManager) save(ctx context.Context, authorizerAppID string, token AuthorizerToken) error {
+	cached := &cachedAuthorizerToken{token: token, unsaved: true}
+	m.mu.Lock()
+	m.cache[authorizerAppID] = cached
+	m.mu.Unlock()
+
+	if err := m.store.SetAuthorizerToken(ctx, m.componentAppID, authorizerAppID, &token); err != nil {
+		return fmt.Errorf("save authorizer %v token: %w", authorizerAppID, err)
+	}
+
+	m.mu.Lock()
+	cached.unsaved = false
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *AuthorizerTokenManager) lock(ctx context.Context, authorizerAppID string) error {
+	m.mu.Lock()
+	l, ok := m.locks[authorizerAppID]
+	if !ok {
+		l = make(chan struct{}, 1)
+		m.locks[authorizerAppID] = l
+	}
+	m.mu.Unlock()
+
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *AuthorizerTokenManager) unlock(authorizerAppID string) {
+	m.mu.Lock()
+	l := m.locks[authorizerAppID]
+	m.mu.Unlock()
+	<-l
+}
+
+func (m *AuthorizerTokenManager) margin() time.Duration {
+	if m.RefreshMargin > 0 {
+		return m.RefreshMargin
+	}
+	return defaultRefreshMargin
+}
+
+type authorizerTokenSource struct {
+	m               *AuthorizerTokenManager
+	authorizerAppID string
+}
+
+// Token implements TokenSource interface.
+func (s authorizerTokenSource) Token(ctx context.Context) (string, error) {
+	return s.m.Token(ctx, s.authorizerAppID)
+}
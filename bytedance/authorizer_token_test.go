@@ -0,0 +1,108 @@
+package bytedance_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+func TestAuthorizerTokenManager_Refresh_serialized(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	store := bytedance.NewMemoryTokenStore()
+	c, ts, appID, _ := setupAuthorizer(t, s, store)
+	m := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts)
+	ctx := context.Background()
+
+	// the server rejects a refresh token used twice.
+	const n = 10
+	tokens := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := m.Refresh(ctx, appID)
+			if err != nil {
+				t.Errorf("Refresh returned error: %v", err)
+				return
+			}
+			mu.Lock()
+			tokens[token] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(tokens) != n {
+		t.Errorf("Refresh returned %d distinct tokens, want %d", len(tokens), n)
+	}
+
+	// another replica continues with the rotated refresh token in store.
+	replica := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts)
+	if _, err := replica.Refresh(ctx, appID); err != nil {
+		t.Errorf("Refresh of replica returned error: %v", err)
+	}
+	if _, err := m.Refresh(ctx, appID); err != nil {
+		t.Errorf("Refresh after replica returned error: %v", err)
+	}
+}
+
+func TestAuthorizerTokenManager_Renew(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	store := bytedance.NewMemoryTokenStore()
+	c, ts, appID, stale := setupAuthorizer(t, s, store)
+	m := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts)
+	ctx := context.Background()
+
+	if token, err := m.Token(ctx, appID); err != nil || token != stale {
+		t.Fatalf("Token returned %v, %v, want the stored %v", token, err, stale)
+	}
+
+	tokens := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if tokens[i], err = m.Renew(ctx, appID, stale); err != nil {
+				t.Errorf("Renew returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, token := range tokens {
+		if token == stale || token != tokens[0] {
+			t.Errorf("Renew returned %v, want one new token %v", token, tokens[0])
+		}
+	}
+
+	saved, err := store.GetAuthorizerToken(ctx, bytedancetest.ComponentAppID, appID)
+	if err != nil {
+		t.Fatalf("GetAuthorizerToken returned error: %v", err)
+	}
+	if saved.AccessToken != tokens[0] {
+		t.Errorf("TokenStore has %v, want %v", saved.AccessToken, tokens[0])
+	}
+	if got, ok := m.AuthorizerAppID(tokens[0]); !ok || got != appID {
+		t.Errorf("AuthorizerAppID returned %v, %v, want %v", got, ok, appID)
+	}
+}
+
+func TestAuthorizerTokenManager_Token_unknown(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c := s.Client()
+	ts := bytedance.NewComponentTokenSource(c, bytedancetest.ComponentAppID, bytedancetest.ComponentAppSecret,
+		bytedance.StaticTicket(s.Ticket()))
+	m := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts)
+	if _, err := m.Token(context.Background(), "unknown"); !errors.Is(err, bytedance.ErrTokenNotFound) {
+		t.Errorf("Token returned error %v, want ErrTokenNotFound", err)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 const (
@@ -20,7 +22,8 @@ const (
 
 // Client manages communication with the bytedance API.
 type Client struct {
-	client *http.Client
+	client  *http.Client
+	timeout time.Duration // set by WithTimeout
 
 	// Base URL for API.requests.
 	// BaseURL should always be specified with a trailing slash.
@@ -29,6 +32,42 @@ type Client struct {
 	// User agent used when communication with bytedance API.
 	UserAgent string
 
+	// RetryPolicy retries failed requests when not nil.
+	RetryPolicy *RetryPolicy
+
+	// Reauthenticate, when not nil, is called for a new token if a request is
+	// rejected for an invalid or expired token, the request is then replayed
+	// once with the new token.
+	Reauthenticate ReauthFunc
+
+	// RateLimiter, when not nil, delays each attempt of a request until it's
+	// allowed by the limits of its endpoint and authorizer.
+	RateLimiter *RateLimiter
+
+	// Logger, when not nil, logs every API call.
+	Logger Logger
+
+	// Interceptors intercept every attempt of API calls, the first one is the outermost.
+	Interceptors []Interceptor
+
+	// Tracer, when not nil, starts a span for every API call.
+	Tracer Tracer
+
+	// Metrics, when not nil, is called after every API call.
+	Metrics MetricsCollector
+
+	// TokenStore persists tickets and tokens of the auth flows when not nil,
+	// so that replicas of a service can share them.
+	TokenStore TokenStore
+
+	// Platforms, when not nil, resolves the credentials of calls of the
+	// third party platforms it holds, see NewRegistry.
+	Platforms *Registry
+
+	// SkipValidation disables calling Validate of request bodies implementing
+	// Validator in NewRequest.
+	SkipValidation bool
+
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// Services used for talking to different parts of bytedance API.
@@ -40,10 +79,18 @@ type service struct {
 	client *Client
 }
 
-// NewClient returns a new bytedance API client.
-func NewClient() *Client {
+// NewClient returns a new bytedance API client configured by opts.
+func NewClient(opts ...Option) *Client {
 	baseURL, _ := url.Parse(defaultBaseURL)
 	c := Client{BaseURL: baseURL, UserAgent: userAgent, client: &http.Client{}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.timeout > 0 {
+		hc := *c.client
+		hc.Timeout = c.timeout
+		c.client = &hc
+	}
 	c.common.client = &c
 	c.ThirdParty = (*ThirdPartyService)(&c.common)
 	c.MicroApp = (*MicroAppService)(&c.common)
@@ -54,8 +101,14 @@ func NewClient() *Client {
 // in which case it is resolved relative to the BaseURL of the Client.
 // Relative URLs should always be specified without a preceding slash.
 // If specified, the value pointed to by body is JSON encoded and included as
-// request body.
+// request body. A body implementing Validator is validated first, unless
+// SkipValidation is set.
 func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	if v, ok := body.(Validator); ok && !c.SkipValidation {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	u, err := c.BaseURL.Parse(urlStr)
 	if err != nil {
 		return nil, err
@@ -111,6 +164,12 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	return req, nil
 }
 
+// withQuery returns path with params encoded as its query string, so that
+// values with non-ASCII or reserved characters are escaped.
+func withQuery(path string, params url.Values) string {
+	return path + "?" + params.Encode()
+}
+
 // FormRender for form with file request.
 type FormRender interface {
 	Params() map[string]string
@@ -118,12 +177,24 @@ type FormRender interface {
 }
 
 // Response represents bytedance Response.
+// Data is the raw data of the response, including fields not modeled by the
+// returned structs.
 type Response struct {
 	*http.Response
 
 	ErrNo   int             `json:"errno"`
 	Message string          `json:"message"`
 	Data    json.RawMessage `json:"data"`
+
+	// LogID is the server log ID of the request, ByteDance support asks for it
+	// in tickets.
+	LogID string `json:"-"`
+
+	// Duration is the time spent on the call, including retries.
+	Duration time.Duration `json:"-"`
+
+	// Attempts is the number of requests sent for the call.
+	Attempts int `json:"-"`
 }
 
 // ErrorResponse represents bytedance Error Response.
@@ -136,10 +207,20 @@ type ErrorResponse struct {
 }
 
 // Error implements builtin.error interface.
+// Secrets in the request URL and body are redacted.
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v body %s : %d %v",
-		r.Response.Request.Method, r.Response.Request.URL, r.requestBody,
+	msg := fmt.Sprintf("%v %v body %s : %d %v",
+		r.Response.Request.Method, RedactURL(r.Response.Request.URL), RedactJSON(r.requestBody),
 		r.ErrNo, r.Message)
+	if logID := r.LogID(); logID != "" {
+		msg += " (log id " + logID + ")"
+	}
+	return msg
+}
+
+// LogID returns the server log ID of the failed request.
+func (r *ErrorResponse) LogID() string {
+	return r.Response.Header.Get(HeaderLogID)
 }
 
 func newResponse(r *http.Response) (*Response, error) {
@@ -175,56 +256,109 @@ func CheckResponse(r *Response, reqBody []byte) error {
 // JSON decoded and stored in the value pointed to by v, or returned as an
 // error if an API error has occurred. If v implements the io.Writer interface,
 // the raw response body will be written to v, without attempting to first
-// decode it.
+// decode it, and a non-JSON body is streamed without being buffered. A non-2xx
+// status is then returned as *ErrorResponse without writing the body. Failed
+// requests are retried according to the RetryPolicy of c, and replayed once
+// with a new token from Reauthenticate if the token is rejected.
 //
 // The provided ctx must be non-nil, if it is nil an error is returned. If it
 // is canceled or timeout, ctx.Err() will be returned.
-func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
 	if ctx == nil {
 		return nil, errors.New("context must be non-nil")
 	}
+	if c.Platforms != nil {
+		var err error
+		if ctx, err = c.Platforms.resolve(ctx, req); err != nil {
+			return nil, err
+		}
+	}
 
+	endpoint := c.endpointName(req.URL)
+	var span Span
+	if c.Tracer != nil {
+		ctx, span = c.Tracer.StartSpan(ctx, endpoint)
+	}
+	if _, ok := v.(io.Writer); ok {
+		ctx = context.WithValue(ctx, streamKey{}, true)
+	}
 	req = req.WithContext(ctx)
 
-	// save body for display request error info.
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		return nil, errors.New("read request body error")
+	// save body for display request error info and retries.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			err = errors.New("read request body error")
+			if span != nil {
+				span.End(SpanInfo{Endpoint: endpoint, Err: err})
+			}
+			return nil, err
+		}
 	}
 
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-
-	resp, err := c.client.Do(req)
+	start := time.Now()
+	response, attempts, err := c.sendWithRetry(ctx, req, body)
 	if err != nil {
-		// If we got an error, and the context has been canceled,
-		// the context's error id probably more useful.
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+		// replay once with a new token if the token was rejected.
+		reauthReq, reauthErr := c.reauthRequest(ctx, req, err)
+		switch {
+		case reauthErr != nil:
+			err = &ReauthError{Err: err, ReauthErr: reauthErr}
+		case reauthReq != nil:
+			var n int
+			response, n, err = c.sendWithRetry(ctx, reauthReq, body)
+			attempts += n
 		}
-		return nil, err
-
 	}
-
-	response, err := newResponse(resp)
-	if err != nil {
+	duration := time.Since(start)
+
+	var statusCode, errNo int
+	var logID string
+	if response != nil {
+		errNo = response.ErrNo
+		if response.Response != nil {
+			statusCode = response.StatusCode
+			logID = response.Header.Get(HeaderLogID)
+		}
+	}
+	c.logCall(req, statusCode, errNo, err, duration)
+	if span != nil {
+		span.End(SpanInfo{
+			Endpoint:   endpoint,
+			StatusCode: statusCode,
+			ErrNo:      errNo,
+			Retries:    attempts - 1,
+			LogID:      logID,
+			Err:        err,
+		})
+	}
+	if c.Metrics != nil {
+		c.Metrics.ObserveRequest(endpoint, duration, statusCode, errNo)
+	}
+	if response == nil {
 		return nil, err
 	}
-
-	if err := CheckResponse(response, body); err != nil {
-		return resp, err
+	response.LogID, response.Duration, response.Attempts = logID, duration, attempts
+	if err != nil {
+		return response, err
 	}
 
 	if v != nil {
 		if w, ok := v.(io.Writer); ok {
-			io.Copy(w, resp.Body)
+			if !isSuccess(response.Response) {
+				// an error page, e.g. of a gateway, is not the content.
+				response.Body.Close()
+				return response, &ErrorResponse{Response: response.Response, Message: response.Status, requestBody: body}
+			}
+			_, err = io.Copy(w, response.Body)
+			response.Body.Close()
 		} else {
 			var data io.Reader
 			if len(response.Data) > 0 {
 				data = bytes.NewReader(response.Data)
 			} else {
-				data = resp.Body
+				data = response.Body
 			}
 			decErr := json.NewDecoder(data).Decode(v)
 			if decErr == io.EOF {
@@ -235,6 +369,97 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 			}
 		}
 	}
-	return resp, err
+	return response, err
+
+}
+
+// logCall logs a finished call to c.Logger.
+func (c *Client) logCall(req *http.Request, status, errNo int, err error, d time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+	if err != nil {
+		c.Logger.Printf("bytedance: %v %v status=%d errno=%d duration=%v error=%v",
+			req.Method, c.endpointPath(req.URL), status, errNo, d, err)
+		return
+	}
+	c.Logger.Printf("bytedance: %v %v status=%d errno=%d duration=%v",
+		req.Method, c.endpointPath(req.URL), status, errNo, d)
+}
+
+// endpointPath returns the path of u relative to BaseURL, e.g. "v1/microapp/app/info".
+func (c *Client) endpointPath(u *url.URL) string {
+	return strings.TrimPrefix(strings.TrimPrefix(u.Path, c.BaseURL.Path), "/")
+}
+
+// endpointName returns the logical name of the endpoint of u, i.e. its path
+// without version joined by dots, e.g. "microapp.package.upload".
+func (c *Client) endpointName(u *url.URL) string {
+	path := c.endpointPath(u)
+	if i := strings.Index(path, "/"); i > 0 && path[0] == 'v' && isDigits(path[1:i]) {
+		path = path[i+1:]
+	}
+	return strings.Replace(path, "/", ".", -1)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// sendWithRetry sends req with body, retrying according to c.RetryPolicy.
+// It returns the last response and the number of attempts made.
+func (c *Client) sendWithRetry(ctx context.Context, req *http.Request, body []byte) (*Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := c.send(ctx, req, body)
+		if !c.RetryPolicy.shouldRetry(req, attempt, response, err) {
+			return response, attempt, err
+		}
+		if response != nil && response.Response != nil {
+			response.Body.Close()
+		}
+		if waitErr := c.RetryPolicy.wait(ctx, attempt); waitErr != nil {
+			return response, attempt, waitErr
+		}
+	}
+}
+
+// send makes a single attempt of req with body.
+func (c *Client) send(ctx context.Context, req *http.Request, body []byte) (*Response, error) {
+	req = req.Clone(ctx)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	if c.RateLimiter != nil {
+		authorizerAppID, _ := AuthorizerAppIDFromContext(ctx)
+		if _, err := c.RateLimiter.Wait(ctx, c.endpointPath(req.URL), authorizerAppID); err != nil {
+			return nil, err
+		}
+	}
 
+	call := &Call{Endpoint: c.endpointName(req.URL), Request: req}
+	if err := c.invoke(ctx, call); err != nil {
+		return call.Response, err
+	}
+	response := call.Response
+	if response == nil {
+		return nil, errors.New("no response from interceptors")
+	}
+	if response.Response == nil {
+		// response made up by an interceptor.
+		response.Response = &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    call.Request,
+		}
+	}
+	return response, CheckResponse(response, body)
 }
@@ -0,0 +1,160 @@
+package bytedance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client of opts talking to a test server serving h,
+// the server is closed when the test finishes.
+func newTestClient(t *testing.T, h http.Handler, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	baseURL, _ := url.Parse(srv.URL + "/")
+	return NewClient(append([]Option{WithBaseURL(baseURL)}, opts...)...)
+}
+
+// bufferLogger is a Logger writing to a buffer.
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format+"\n", v...)
+}
+
+// setup returns a client talking to a test server, and the queries it received.
+func setup(t *testing.T) (*Client, *[]url.Values) {
+	t.Helper()
+	var queries []url.Values
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errno":0,"message":"success","data":{}}`))
+	}))
+	return c, &queries
+}
+
+func TestWithQuery_escapesValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		call  func(c *Client) error
+		param string
+		want  string
+	}{
+		{
+			name: "chinese app name",
+			call: func(c *Client) error {
+				_, err := c.MicroApp.CheckAppName(context.Background(), "appid", "token", "字节小程序")
+				return err
+			},
+			param: "app_name",
+			want:  "字节小程序",
+		},
+		{
+			name: "app name with ampersand",
+			call: func(c *Client) error {
+				_, err := c.MicroApp.CheckAppName(context.Background(), "appid", "token", "A&B=C")
+				return err
+			},
+			param: "app_name",
+			want:  "A&B=C",
+		},
+		{
+			name: "ticket with plus and equals",
+			call: func(c *Client) error {
+				_, _, err := c.ThirdParty.GetComponentAccessToken(context.Background(), "appid", "secret", "ab+cd/ef==")
+				return err
+			},
+			param: "component_ticket",
+			want:  "ab+cd/ef==",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, queries := setup(t)
+			if err := tt.call(c); err != nil {
+				t.Fatalf("call returned error: %v", err)
+			}
+			if len(*queries) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(*queries))
+			}
+			q := (*queries)[0]
+			if got := q[tt.param]; len(got) != 1 || got[0] != tt.want {
+				t.Errorf("query %v is %q, want %q", tt.param, got, tt.want)
+			}
+			if got := q.Get("component_appid"); got != "appid" {
+				t.Errorf("query component_appid is %q, want %q", got, "appid")
+			}
+		})
+	}
+}
+
+func TestServices_pathAndCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(c *Client) error
+		path      string
+		wantQuery url.Values
+	}{
+		{
+			name: "download webview file",
+			call: func(c *Client) error {
+				_, err := c.ThirdParty.DownloadWebViewFile(context.Background(), "appid", "token", nil)
+				return err
+			},
+			path:      "/v1/tp/download/webview_file",
+			wantQuery: url.Values{"component_appid": {"appid"}, "component_access_token": {"token"}},
+		},
+		{
+			name: "upload pic material",
+			call: func(c *Client) error {
+				_, _, err := c.ThirdParty.UploadPicMaterial(context.Background(), "appid", "token",
+					&UploadPicMaterialRequest{MaterialType: 1, MaterialFile: &File{Name: "a.png", Content: strings.NewReader("png")}})
+				return err
+			},
+			path:      "/v1/tp/upload_pic_material",
+			wantQuery: url.Values{"component_appid": {"appid"}, "component_access_token": {"token"}},
+		},
+		{
+			name: "modify app icon",
+			call: func(c *Client) error {
+				_, err := c.MicroApp.ModifyAppIcon(context.Background(), "appid", "token",
+					&ModifyAppIconRequest{NewIconPath: "path"})
+				return err
+			},
+			path:      "/v1/microapp/app/modify_app_icon",
+			wantQuery: url.Values{"component_appid": {"appid"}, "authorizer_access_token": {"token"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*url.URL
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = append(got, r.URL)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"errno":0,"message":"success","data":"address"}`))
+			}))
+			if err := tt.call(c); err != nil {
+				t.Fatalf("call returned error: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(got))
+			}
+			if got[0].Path != tt.path {
+				t.Errorf("request path is %v, want %v", got[0].Path, tt.path)
+			}
+			if q := got[0].Query(); !reflect.DeepEqual(q, tt.wantQuery) {
+				t.Errorf("request query is %v, want %v", q, tt.wantQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package bytedancetest provides utilities for testing code using the bytedance package.
+package bytedancetest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// Modes of Recorder.
+const (
+	// ModeReplay replays recorded interactions without network access.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests through the real transport and records them.
+	ModeRecord
+)
+
+// Cassette is the content of a recording file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request, secrets in its query and body are redacted.
+// Body is informational, it's not used to match requests.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response, secrets in its body are redacted.
+// Body is base64 encoded if it's binary, e.g. an image.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	Base64     bool        `json:"base64,omitempty"`
+}
+
+// Recorder is a cassette-style http.RoundTripper. In ModeRecord it records real
+// interactions with tokens and secrets redacted, in ModeReplay it replays them
+// offline, matching requests by method, path and normalized query. Every
+// recorded interaction is replayed once in order of recording.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed []bool
+}
+
+// NewRecorder returns a Recorder of the recording file at path. In ModeReplay
+// the file is loaded, in ModeRecord requests are sent through transport,
+// http.DefaultTransport if nil, and Save writes them to the file.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, transport: transport, cassette: new(Cassette)}
+	if mode == ModeReplay {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, r.cassette); err != nil {
+			return nil, err
+		}
+		r.replayed = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Client returns an HTTP client using r as transport, to be used with bytedance.WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// the body is sent again from a clone, req must not be modified.
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  normalizeQuery(req.URL.RawQuery),
+	}
+	// binary bodies, e.g. uploaded images, are not recorded.
+	if utf8.Valid(body) {
+		recorded.Body = string(bytedance.RedactJSON(body))
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+// Save writes the recorded interactions to the recording file.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return errors.New("recorder is not recording")
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, b, 0600)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	response := RecordedResponse{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+	response.Header.Del("Set-Cookie")
+	if utf8.Valid(data) {
+		response.Body = string(bytedance.RedactJSON(data))
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(data)
+		response.Base64 = true
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || !matches(interaction.Request, recorded) {
+			continue
+		}
+		r.replayed[i] = true
+
+		body := []byte(interaction.Response.Body)
+		if interaction.Response.Base64 {
+			var err error
+			if body, err = base64.StdEncoding.DecodeString(interaction.Response.Body); err != nil {
+				return nil, err
+			}
+		}
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %v %v?%v", recorded.Method, recorded.Path, recorded.Query)
+}
+
+func matches(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.Path == b.Path && a.Query == b.Query
+}
+
+// normalizeQuery returns rawQuery with sorted parameters and sensitive values
+// redacted, so that queries match regardless of order and tokens.
+func normalizeQuery(rawQuery string) string {
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return bytedance.RedactQuery(rawQuery)
+	}
+	for key, values := range q {
+		if bytedance.IsSensitiveKey(key) {
+			for i := range values {
+				values[i] = "REDACTED"
+			}
+		}
+	}
+	return q.Encode()
+}
+
+// ModeFor returns ModeReplay if the recording file at path exists, otherwise ModeRecord.
+func ModeFor(path string) Mode {
+	if _, err := os.Stat(path); err == nil {
+		return ModeReplay
+	}
+	return ModeRecord
+}
@@ -0,0 +1,158 @@
+package bytedancetest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+// recordedFlow is what a flow run through a Recorder got.
+type recordedFlow struct {
+	secrets []string
+	appName string
+	webview []byte
+}
+
+// runFlow authorizes an app with ticket and code, and calls the endpoints of
+// it through c.
+func runFlow(t *testing.T, c *bytedance.Client, ticket, code string) *recordedFlow {
+	t.Helper()
+	ctx := context.Background()
+	ts := bytedance.NewComponentTokenSource(c, bytedancetest.ComponentAppID, bytedancetest.ComponentAppSecret,
+		bytedance.StaticTicket(ticket))
+	componentToken, err := ts.Token(ctx)
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	oAuthToken, err := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts).Authorize(ctx, code)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	info, _, err := c.MicroApp.GetAppInfo(ctx, bytedancetest.ComponentAppID, oAuthToken.AuthorizerAccessToken)
+	if err != nil {
+		t.Fatalf("GetAppInfo returned error: %v", err)
+	}
+	var webview bytes.Buffer
+	if _, err := c.ThirdParty.DownloadWebViewFileTo(ctx, bytedancetest.ComponentAppID, componentToken,
+		&webview); err != nil {
+		t.Fatalf("DownloadWebViewFileTo returned error: %v", err)
+	}
+
+	// the same call with parameters in another order.
+	u := "v1/microapp/app/info?authorizer_access_token=" + url.QueryEscape(oAuthToken.AuthorizerAccessToken) +
+		"&component_appid=" + bytedancetest.ComponentAppID
+	req, err := c.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(ctx, req, nil); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	return &recordedFlow{
+		secrets: []string{ticket, code, bytedancetest.ComponentAppSecret, componentToken,
+			oAuthToken.AuthorizerAccessToken, oAuthToken.AuthorizerRefreshToken},
+		appName: info.AppName,
+		webview: webview.Bytes(),
+	}
+}
+
+// offlineTransport fails every request, replays must not reach the network.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bytedancetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+	if mode := bytedancetest.ModeFor(path); mode != bytedancetest.ModeRecord {
+		t.Errorf("ModeFor of missing file returned %v, want ModeRecord", mode)
+	}
+
+	s := bytedancetest.NewServer()
+	rec, err := bytedancetest.NewRecorder(path, bytedancetest.ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	_, code := s.AddAuthorizer("recorded app")
+	recorded := runFlow(t, s.Client(bytedance.WithHTTPClient(rec.Client())), s.Ticket(), code)
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	baseURL := s.URL
+	s.Close()
+
+	cassette, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range recorded.secrets {
+		if strings.Contains(string(cassette), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+
+	// the replay matches requests with other tokens and secrets.
+	if mode := bytedancetest.ModeFor(path); mode != bytedancetest.ModeReplay {
+		t.Errorf("ModeFor of saved file returned %v, want ModeReplay", mode)
+	}
+	rec, err = bytedancetest.NewRecorder(path, bytedancetest.ModeReplay, offlineTransport{})
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	u, _ := url.Parse(baseURL + "/")
+	c := bytedance.NewClient(bytedance.WithBaseURL(u), bytedance.WithHTTPClient(rec.Client()))
+	replayed := runFlow(t, c, "other ticket", "other code")
+	if replayed.appName != recorded.appName {
+		t.Errorf("replayed app name %q, want %q", replayed.appName, recorded.appName)
+	}
+	if !bytes.Equal(replayed.webview, recorded.webview) {
+		t.Errorf("replayed webview file %q, want %q", replayed.webview, recorded.webview)
+	}
+
+	// every interaction is replayed once.
+	if _, _, err := c.MicroApp.GetAppInfo(context.Background(), bytedancetest.ComponentAppID, "token"); err == nil {
+		t.Error("GetAppInfo beyond the recorded interactions returned no error")
+	}
+}
+
+func TestRecorder_RoundTrip_keepsRequest(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "bytedancetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rec, err := bytedancetest.NewRecorder(filepath.Join(dir, "cassette.json"), bytedancetest.ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+
+	body := ioutil.NopCloser(strings.NewReader(`{"draft_id":1}`))
+	req, _ := http.NewRequest(http.MethodPost, s.URL+"/v1/tp/template/add_tpl", body)
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	resp.Body.Close()
+	if req.Body != body {
+		t.Error("RoundTrip replaced the body of the request")
+	}
+}
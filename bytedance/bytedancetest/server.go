@@ -0,0 +1,775 @@
+package bytedancetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+)
+
+// Credentials of the third party platform served by Server.
+const (
+	ComponentAppID     = "tt_component_appid"
+	ComponentAppSecret = "tt_component_appsecret"
+)
+
+// ErrNo values returned by Server, they are in the errno catalog of the bytedance package.
+const (
+	ErrNoInvalidParameter = 40001
+	ErrNoInvalidToken     = 40002
+	ErrNoPermission       = 40006
+)
+
+// tokenExpiresIn is the lifetime of tokens issued by Server in seconds.
+const tokenExpiresIn = 7200
+
+// apiError is an errno response.
+type apiError struct {
+	errNo   int
+	message string
+}
+
+func errInvalidParameter(format string, v ...interface{}) *apiError {
+	return &apiError{errNo: ErrNoInvalidParameter, message: fmt.Sprintf(format, v...)}
+}
+
+// rawBody is a non-JSON response body.
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// fault is a scripted errno response.
+type fault struct {
+	errNo   int
+	message string
+	times   int
+}
+
+// app is the state of an authorized micro app.
+type app struct {
+	info         bytedance.AppInfo
+	versions     bytedance.PackageVersions
+	history      []*bytedance.Current
+	serverDomain bytedance.ServerDomain
+	webview      []string
+}
+
+// Server is an in-process fake of the Open Platform, serving the auth,
+// template, draft, package, domain and app info endpoints with realistic
+// state: uploading a package creates a Latest version, an audit moves it to
+// Audit and a release moves it to Current. Errno responses can be scripted
+// with InjectErrNo.
+type Server struct {
+	*httptest.Server
+
+	mu              sync.Mutex
+	seq             int
+	ticket          string
+	componentTokens map[string]bool
+	preAuthCodes    map[string]bool
+	authCodes       map[string]string // authorization code to authorizer appid
+	accessTokens    map[string]string // authorizer access token to authorizer appid
+	refreshTokens   map[string]string // authorizer refresh token to authorizer appid
+	apps            map[string]*app
+	drafts          []*bytedance.Draft
+	templates       []*bytedance.Template
+	faults          map[string][]*fault
+}
+
+// NewServer starts and returns a new Server, the caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		componentTokens: make(map[string]bool),
+		preAuthCodes:    make(map[string]bool),
+		authCodes:       make(map[string]string),
+		accessTokens:    make(map[string]string),
+		refreshTokens:   make(map[string]string),
+		apps:            make(map[string]*app),
+		faults:          make(map[string][]*fault),
+	}
+	s.ticket = s.nextID("ticket")
+
+	mux := http.NewServeMux()
+	handlers := map[string]func(r *http.Request) (interface{}, *apiError){
+		"v1/auth/tp/token":                      s.componentAccessToken,
+		"v2/auth/pre_auth_code":                 s.withComponentToken(s.preAuthCode),
+		"v1/oauth/token":                        s.withComponentToken(s.oAuthToken),
+		"v1/auth/retrieve":                      s.withComponentToken(s.retrieveAuthorizationCode),
+		"v1/tp/template/get_tpl_list":           s.withComponentToken(s.templateList),
+		"v1/tp/template/get_draft_list":         s.withComponentToken(s.draftList),
+		"v1/tp/template/add_tpl":                s.withComponentToken(s.addTemplate),
+		"v1/tp/template/del_tpl":                s.withComponentToken(s.deleteTemplate),
+		"v1/tp/download/webview_file":           s.withComponentToken(s.webviewFile),
+		"v1/tp/upload_pic_material":             s.withComponentToken(s.uploadPicMaterial),
+		"v1/microapp/app/info":                  s.withAuthorizer(s.appInfo),
+		"v1/microapp/app/qrcode":                s.withAuthorizer(s.qrcode),
+		"v1/microapp/app/check_app_name":        s.withAuthorizer(s.checkAppName),
+		"v1/microapp/app/modify_app_name":       s.withAuthorizer(s.modifyAppName),
+		"v1/microapp/app/modify_app_intro":      s.withAuthorizer(s.modifyAppIntro),
+		"v1/microapp/app/modify_app_icon":       s.withAuthorizer(s.modifyAppIcon),
+		"v1/microapp/app/modify_server_domain":  s.withAuthorizer(s.modifyServerDomain),
+		"v1/microapp/app/modify_webview_domain": s.withAuthorizer(s.modifyWebviewDomain),
+		"v1/microapp/code2session":              s.withAuthorizer(s.code2Session),
+		"v1/microapp/package/upload":            s.withAuthorizer(s.uploadPackage),
+		"v1/microapp/package/audit_hosts":       s.withAuthorizer(s.auditHosts),
+		"v2/microapp/package/audit":             s.withAuthorizer(s.auditPackage),
+		"v1/microapp/package/release":           s.withAuthorizer(s.releasePackage),
+		"v1/microapp/package/rollback":          s.withAuthorizer(s.rollbackPackage),
+		"v1/microapp/package/versions":          s.withAuthorizer(s.packageVersions),
+	}
+	for path, h := range handlers {
+		mux.Handle("/"+path, s.serve(endpointName(path), h))
+	}
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Client returns a bytedance.Client talking to s, configured by opts.
+func (s *Server) Client(opts ...bytedance.Option) *bytedance.Client {
+	u, _ := url.Parse(s.URL + "/")
+	return bytedance.NewClient(append([]bytedance.Option{bytedance.WithBaseURL(u)}, opts...)...)
+}
+
+// Ticket returns the current component_ticket, as if it was pushed by the platform.
+func (s *Server) Ticket() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ticket
+}
+
+// RotateTicket issues a new component_ticket and returns it.
+func (s *Server) RotateTicket() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ticket = s.nextID("ticket")
+	return s.ticket
+}
+
+// AddAuthorizer creates a micro app named appName, and returns its appid and an
+// authorization code to exchange for its tokens.
+func (s *Server) AddAuthorizer(appName string) (appID, authorizationCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	appID = s.nextID("tt")
+	s.apps[appID] = &app{info: bytedance.AppInfo{AppID: appID, AppName: appName, AppState: 1}}
+	authorizationCode = s.nextID("code")
+	s.authCodes[authorizationCode] = appID
+	return appID, authorizationCode
+}
+
+// AddDraft adds a code draft and returns its ID.
+func (s *Server) AddDraft(userVersion, userDesc string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.drafts = append(s.drafts, &bytedance.Draft{
+		DraftID:     s.seq,
+		UserVersion: userVersion,
+		UserDesc:    userDesc,
+		CreateTime:  now(),
+	})
+	return s.seq
+}
+
+// RevokeTokens invalidates every access token, as after a re-authorization.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.componentTokens = make(map[string]bool)
+	s.accessTokens = make(map[string]string)
+}
+
+// PackageVersions returns the versions of the micro app appID.
+func (s *Server) PackageVersions(appID string) *bytedance.PackageVersions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.apps[appID]
+	if !ok {
+		return nil
+	}
+	return copyVersions(&a.versions)
+}
+
+// InjectErrNo makes the next times calls of endpoint, e.g. "microapp.package.upload",
+// fail with errNo and message. Injected errors are consumed in order.
+func (s *Server) InjectErrNo(endpoint string, errNo int, message string, times int) {
+	if times <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[endpoint] = append(s.faults[endpoint], &fault{errNo: errNo, message: message, times: times})
+}
+
+// serve returns an http.Handler writing the result of h in the response envelope.
+func (s *Server) serve(endpoint string, h func(r *http.Request) (interface{}, *apiError)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(bytedance.HeaderLogID, s.logID())
+		if f := s.takeFault(endpoint); f != nil {
+			writeJSON(w, f.errNo, f.message, nil)
+			return
+		}
+		data, apiErr := h(r)
+		if apiErr != nil {
+			writeJSON(w, apiErr.errNo, apiErr.message, nil)
+			return
+		}
+		if raw, ok := data.(*rawBody); ok {
+			w.Header().Set("Content-Type", raw.contentType)
+			_, _ = w.Write(raw.data)
+			return
+		}
+		writeJSON(w, 0, "success", data)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, errNo int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		ErrNo   int         `json:"errno"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data,omitempty"`
+	}{errNo, message, data})
+}
+
+func (s *Server) takeFault(endpoint string) *fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	faults := s.faults[endpoint]
+	if len(faults) == 0 {
+		return nil
+	}
+	f := faults[0]
+	f.times--
+	if f.times <= 0 {
+		s.faults[endpoint] = faults[1:]
+	}
+	return f
+}
+
+func (s *Server) logID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextID("log")
+}
+
+// nextID returns a new unique ID with prefix, s.mu must be held.
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s_%d_%d", prefix, s.seq, time.Now().UnixNano())
+}
+
+// withComponentToken checks component_appid and component_access_token before h.
+func (s *Server) withComponentToken(h func(r *http.Request) (interface{}, *apiError)) func(
+	r *http.Request) (interface{}, *apiError) {
+	return func(r *http.Request) (interface{}, *apiError) {
+		q := r.URL.Query()
+		if q.Get("component_appid") != ComponentAppID {
+			return nil, errInvalidParameter("invalid component_appid")
+		}
+		s.mu.Lock()
+		ok := s.componentTokens[q.Get(bytedance.ParamComponentAccessToken)]
+		s.mu.Unlock()
+		if !ok {
+			return nil, &apiError{errNo: ErrNoInvalidToken, message: "invalid component_access_token"}
+		}
+		return h(r)
+	}
+}
+
+// withAuthorizer checks component_appid and authorizer_access_token, and passes the app to h.
+func (s *Server) withAuthorizer(h func(r *http.Request, a *app) (interface{}, *apiError)) func(
+	r *http.Request) (interface{}, *apiError) {
+	return func(r *http.Request) (interface{}, *apiError) {
+		q := r.URL.Query()
+		if q.Get("component_appid") != ComponentAppID {
+			return nil, errInvalidParameter("invalid component_appid")
+		}
+		s.mu.Lock()
+		a := s.apps[s.accessTokens[q.Get(bytedance.ParamAuthorizerAccessToken)]]
+		s.mu.Unlock()
+		if a == nil {
+			return nil, &apiError{errNo: ErrNoInvalidToken, message: "invalid authorizer_access_token"}
+		}
+		return h(r, a)
+	}
+}
+
+func decodeBody(r *http.Request, v interface{}) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errInvalidParameter("invalid body: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) componentAccessToken(r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	if q.Get("component_appid") != ComponentAppID || q.Get("component_appsecret") != ComponentAppSecret {
+		return nil, errInvalidParameter("invalid component_appid or component_appsecret")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q.Get("component_ticket") != s.ticket {
+		return nil, errInvalidParameter("invalid component_ticket")
+	}
+	token := s.nextID("component_access_token")
+	s.componentTokens[token] = true
+	return &bytedance.ComponentAccessToken{ComponentAccessToken: token, ExpiresIn: tokenExpiresIn}, nil
+}
+
+func (s *Server) preAuthCode(r *http.Request) (interface{}, *apiError) {
+	var body bytedance.CreatePreAuthCodeRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := s.nextID("pre_auth_code")
+	s.preAuthCodes[code] = true
+	return &bytedance.PreAuthCode{PreAuthCode: code, ExpiresIn: 600}, nil
+}
+
+func (s *Server) oAuthToken(r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var appID string
+	switch q.Get("grant_type") {
+	case bytedance.GrantTypeAuthorizationCode:
+		code := q.Get("authorization_code")
+		if appID = s.authCodes[code]; appID == "" {
+			return nil, errInvalidParameter("invalid authorization_code")
+		}
+		delete(s.authCodes, code)
+	case bytedance.GrantTypeRefreshToken:
+		refreshToken := q.Get("authorizer_refresh_token")
+		if appID = s.refreshTokens[refreshToken]; appID == "" {
+			return nil, errInvalidParameter("invalid authorizer_refresh_token")
+		}
+		// refresh tokens can be used only once.
+		delete(s.refreshTokens, refreshToken)
+	default:
+		return nil, errInvalidParameter("invalid grant_type")
+	}
+
+	for token, id := range s.accessTokens {
+		if id == appID {
+			delete(s.accessTokens, token)
+		}
+	}
+	accessToken, refreshToken := s.nextID("authorizer_access_token"), s.nextID("authorizer_refresh_token")
+	s.accessTokens[accessToken] = appID
+	s.refreshTokens[refreshToken] = appID
+	return &bytedance.OAuthToken{
+		AuthorizerAccessToken:  accessToken,
+		AuthorizerRefreshToken: refreshToken,
+		ExpiresIn:              tokenExpiresIn,
+		AuthorizerAppID:        appID,
+	}, nil
+}
+
+func (s *Server) retrieveAuthorizationCode(r *http.Request) (interface{}, *apiError) {
+	appID := r.URL.Query().Get("authorization_appid")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apps[appID] == nil {
+		return nil, errInvalidParameter("invalid authorization_appid")
+	}
+	code := s.nextID("code")
+	s.authCodes[code] = appID
+	return &bytedance.RetrieveAuthorizationCodeResponse{AuthorizationCode: code, ExpiresIn: 3600}, nil
+}
+
+func (s *Server) templateList(*http.Request) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &bytedance.Templates{TemplateList: append([]*bytedance.Template{}, s.templates...)}, nil
+}
+
+func (s *Server) draftList(*http.Request) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &bytedance.Drafts{DraftList: append([]*bytedance.Draft{}, s.drafts...)}, nil
+}
+
+func (s *Server) addTemplate(r *http.Request) (interface{}, *apiError) {
+	var body bytedance.AddTemplateRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.drafts {
+		if d.DraftID == body.DraftID {
+			s.seq++
+			s.templates = append(s.templates, &bytedance.Template{
+				TemplateID:  s.seq,
+				UserVersion: d.UserVersion,
+				UserDesc:    d.UserDesc,
+				CreateTime:  now(),
+			})
+			return nil, nil
+		}
+	}
+	return nil, errInvalidParameter("draft %d not found", body.DraftID)
+}
+
+func (s *Server) deleteTemplate(r *http.Request) (interface{}, *apiError) {
+	var body bytedance.DeleteTemplateRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.templates {
+		if t.TemplateID == body.TemplateID {
+			s.templates = append(s.templates[:i], s.templates[i+1:]...)
+			return nil, nil
+		}
+	}
+	return nil, errInvalidParameter("template %d not found", body.TemplateID)
+}
+
+func (s *Server) webviewFile(*http.Request) (interface{}, *apiError) {
+	return &rawBody{contentType: "application/octet-stream", data: []byte(ComponentAppID)}, nil
+}
+
+func (s *Server) uploadPicMaterial(r *http.Request) (interface{}, *apiError) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		return nil, errInvalidParameter("invalid form: %v", err)
+	}
+	if _, _, err := r.FormFile("material_file"); err != nil {
+		return nil, errInvalidParameter("material_file is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return "material/" + s.nextID("pic"), nil
+}
+
+func (s *Server) appInfo(_ *http.Request, a *app) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := a.info
+	return &info, nil
+}
+
+// pngHeader is the signature of a PNG file, served as QR code.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func (s *Server) qrcode(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.DownloadQrcodeRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var exists bool
+	switch body.Version {
+	case "current":
+		exists = a.versions.Current != nil
+	case "audit":
+		exists = a.versions.Audit != nil
+	case "latest":
+		exists = a.versions.Latest != nil
+	default:
+		return nil, errInvalidParameter("invalid version %q", body.Version)
+	}
+	if !exists {
+		return nil, errInvalidParameter("version %v not found", body.Version)
+	}
+	data := append(append([]byte{}, pngHeader...), a.info.AppID+"/"+body.Version+"/"+body.Path...)
+	return &rawBody{contentType: "image/png", data: data}, nil
+}
+
+func (s *Server) checkAppName(r *http.Request, a *app) (interface{}, *apiError) {
+	name := r.URL.Query().Get("app_name")
+	if name == "" {
+		return nil, errInvalidParameter("app_name is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.apps {
+		if other != a && other.info.AppName == name {
+			return nil, errInvalidParameter("app_name %v is used", name)
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) modifyAppName(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.ModifyAppNameRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.info.NewNameAuditInfo = &bytedance.NewNameAuditInfo{NewName: body.NewName}
+	return nil, nil
+}
+
+func (s *Server) modifyAppIntro(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.ModifyAppIntroRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.info.NewIntroAuditInfo = &bytedance.NewIntroAuditInfo{NewIntro: body.NewIntro}
+	return nil, nil
+}
+
+func (s *Server) modifyAppIcon(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.ModifyAppIconRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.info.NewIConAuditInfo = &bytedance.NewIconAuditInfo{NewIcon: body.NewIconPath}
+	return nil, nil
+}
+
+// modifyDomains applies action to domains.
+func modifyDomains(action string, domains, values []string) ([]string, *apiError) {
+	switch action {
+	case "get":
+		return domains, nil
+	case "set":
+		return append([]string{}, values...), nil
+	case "add":
+		for _, v := range values {
+			if !contains(domains, v) {
+				domains = append(domains, v)
+			}
+		}
+		return domains, nil
+	case "delete":
+		var kept []string
+		for _, d := range domains {
+			if !contains(values, d) {
+				kept = append(kept, d)
+			}
+		}
+		return kept, nil
+	}
+	return nil, errInvalidParameter("invalid action %q", action)
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) modifyServerDomain(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.ModifyServerDomainRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := a.serverDomain
+	var err *apiError
+	if d.Request, err = modifyDomains(body.Action, d.Request, body.Request); err != nil {
+		return nil, err
+	}
+	d.Socket, _ = modifyDomains(body.Action, d.Socket, body.Socket)
+	d.Upload, _ = modifyDomains(body.Action, d.Upload, body.Upload)
+	d.Download, _ = modifyDomains(body.Action, d.Download, body.Download)
+	a.serverDomain = d
+	return &d, nil
+}
+
+func (s *Server) modifyWebviewDomain(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.ModifyWebviewDomainRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webview, err := modifyDomains(body.Action, a.webview, body.Webview)
+	if err != nil {
+		return nil, err
+	}
+	a.webview = webview
+	return append([]string{}, webview...), nil
+}
+
+func (s *Server) code2Session(r *http.Request, a *app) (interface{}, *apiError) {
+	q := r.URL.Query()
+	code, anonymousCode := q.Get("code"), q.Get("anonymous_code")
+	if code == "" && anonymousCode == "" {
+		return nil, errInvalidParameter("code or anonymous_code is required")
+	}
+	session := &bytedance.Session{SessionKey: "session_key_" + code + anonymousCode}
+	if code != "" {
+		session.OpenID = a.info.AppID + "_openid_" + code
+	}
+	if anonymousCode != "" {
+		session.AnonymousOpenID = a.info.AppID + "_anonymous_openid_" + anonymousCode
+	}
+	return session, nil
+}
+
+func (s *Server) uploadPackage(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.UploadPackageRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found bool
+	for _, t := range s.templates {
+		if t.TemplateID == body.TemplateID {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errInvalidParameter("template %d not found", body.TemplateID)
+	}
+	a.versions.Latest = &bytedance.Latest{
+		VersionCommon: bytedance.VersionCommon{
+			Ctime:   now(),
+			Summary: body.UserDesc,
+			Version: body.UserVersion,
+		},
+	}
+	return nil, nil
+}
+
+// auditHostNames are the hosts which packages can be audited for.
+var auditHostNames = []string{"douyin", "toutiao", "douyin_lite", "toutiao_lite"}
+
+func (s *Server) auditHosts(*http.Request, *app) (interface{}, *apiError) {
+	return &bytedance.PackageAuditHosts{HostNames: auditHostNames}, nil
+}
+
+func (s *Server) auditPackage(r *http.Request, a *app) (interface{}, *apiError) {
+	var body bytedance.CommitAuditPackageRequest
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	for _, host := range body.HostNames {
+		if !contains(auditHostNames, host) {
+			return nil, errInvalidParameter("invalid host %v", host)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.versions.Latest == nil {
+		return nil, errInvalidParameter("no package to audit")
+	}
+	a.versions.Latest.HasAudit = 1
+	a.versions.Audit = &bytedance.Audit{VersionCommon: a.versions.Latest.VersionCommon}
+	a.versions.Audit.Ctime = now()
+	return nil, nil
+}
+
+func (s *Server) releasePackage(_ *http.Request, a *app) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.versions.Audit == nil {
+		return nil, errInvalidParameter("no audited package to release")
+	}
+	a.versions.Audit.HasPublish = 1
+	current := &bytedance.Current{VersionCommon: a.versions.Audit.VersionCommon}
+	current.Ctime = now()
+	if previous := a.versions.Current; previous != nil {
+		a.history = append(a.history, previous)
+		current.LastVersion = previous.Version
+		current.Rollback = &bytedance.Rollback{CanRollback: true, LastVersion: previous.Version}
+	}
+	a.versions.Current = current
+	a.versions.Audit = nil
+	return nil, nil
+}
+
+func (s *Server) rollbackPackage(_ *http.Request, a *app) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(a.history) == 0 {
+		return nil, &apiError{errNo: ErrNoPermission, message: "can not rollback"}
+	}
+	previous := a.history[len(a.history)-1]
+	a.history = a.history[:len(a.history)-1]
+	if len(a.history) > 0 {
+		last := a.history[len(a.history)-1].Version
+		previous.Rollback = &bytedance.Rollback{CanRollback: true, LastVersion: last}
+	} else {
+		previous.Rollback = &bytedance.Rollback{}
+	}
+	a.versions.Current = previous
+	return nil, nil
+}
+
+func (s *Server) packageVersions(_ *http.Request, a *app) (interface{}, *apiError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyVersions(&a.versions), nil
+}
+
+// copyVersions returns a deep copy of v, so that it can be read after s.mu is
+// released while the versions are changed.
+func copyVersions(v *bytedance.PackageVersions) *bytedance.PackageVersions {
+	c := new(bytedance.PackageVersions)
+	if v.Latest != nil {
+		latest := *v.Latest
+		latest.VersionCommon = copyVersionCommon(latest.VersionCommon)
+		c.Latest = &latest
+	}
+	if v.Audit != nil {
+		audit := *v.Audit
+		audit.VersionCommon = copyVersionCommon(audit.VersionCommon)
+		audit.ApprovedApps = append(audit.ApprovedApps[:0:0], audit.ApprovedApps...)
+		audit.AttachInfo = append(audit.AttachInfo[:0:0], audit.AttachInfo...)
+		audit.ReasonDetail = append(audit.ReasonDetail[:0:0], audit.ReasonDetail...)
+		c.Audit = &audit
+	}
+	if v.Current != nil {
+		current := *v.Current
+		current.VersionCommon = copyVersionCommon(current.VersionCommon)
+		current.ApprovedApps = append(current.ApprovedApps[:0:0], current.ApprovedApps...)
+		current.NotApprovedApps = append(current.NotApprovedApps[:0:0], current.NotApprovedApps...)
+		current.AttachInfo = append(current.AttachInfo[:0:0], current.AttachInfo...)
+		current.ReasonDetail = append(current.ReasonDetail[:0:0], current.ReasonDetail...)
+		if current.Rollback != nil {
+			rollback := *current.Rollback
+			current.Rollback = &rollback
+		}
+		c.Current = &current
+	}
+	return c
+}
+
+func copyVersionCommon(v bytedance.VersionCommon) bytedance.VersionCommon {
+	v.Categories = append(v.Categories[:0:0], v.Categories...)
+	if v.Ctime != nil {
+		ctime := *v.Ctime
+		v.Ctime = &ctime
+	}
+	return v
+}
+
+func now() *bytedance.Timestamp {
+	return &bytedance.Timestamp{Time: time.Now().Truncate(time.Second)}
+}
+
+// endpointName returns the logical name of an endpoint path, e.g.
+// "microapp.package.upload" of "v1/microapp/package/upload".
+func endpointName(path string) string {
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i+1:]
+	}
+	return strings.Replace(path, "/", ".", -1)
+}
@@ -0,0 +1,115 @@
+package bytedancetest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+// authorize returns a client of s, a new authorizer and its access token.
+func authorize(t *testing.T, s *bytedancetest.Server) (c *bytedance.Client, componentToken, appID, token string) {
+	t.Helper()
+	ctx := context.Background()
+	c = s.Client()
+	ts := bytedance.NewComponentTokenSource(c, bytedancetest.ComponentAppID, bytedancetest.ComponentAppSecret,
+		bytedance.StaticTicket(s.Ticket()))
+	componentToken, err := ts.Token(ctx)
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	appID, code := s.AddAuthorizer("app")
+	oAuthToken, err := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts).Authorize(ctx, code)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	return c, componentToken, appID, oAuthToken.AuthorizerAccessToken
+}
+
+// audit uploads a package of a new template of version and commits it to audit.
+func audit(t *testing.T, s *bytedancetest.Server, c *bytedance.Client, componentToken, token, version string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := c.ThirdParty.AddTemplate(ctx, bytedancetest.ComponentAppID, componentToken,
+		&bytedance.AddTemplateRequest{DraftID: s.AddDraft(version, "desc")}); err != nil {
+		t.Fatalf("AddTemplate returned error: %v", err)
+	}
+	templates, _, err := c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, componentToken)
+	if err != nil || len(templates.TemplateList) == 0 {
+		t.Fatalf("GetTemplates returned %+v, %v", templates, err)
+	}
+	template := templates.TemplateList[len(templates.TemplateList)-1]
+	if _, err := c.MicroApp.UploadPackage(ctx, bytedancetest.ComponentAppID, token, &bytedance.UploadPackageRequest{
+		TemplateID: template.TemplateID, UserVersion: version, UserDesc: "desc",
+	}); err != nil {
+		t.Fatalf("UploadPackage returned error: %v", err)
+	}
+	if _, err := c.MicroApp.CommitAuditPackage(ctx, bytedancetest.ComponentAppID, token,
+		&bytedance.CommitAuditPackageRequest{HostNames: []string{"douyin"}}); err != nil {
+		t.Fatalf("CommitAuditPackage returned error: %v", err)
+	}
+}
+
+func TestServer_packageFlow(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, componentToken, appID, token := authorize(t, s)
+	ctx := context.Background()
+
+	audit(t, s, c, componentToken, token, "1.0.0")
+	// versions returned before the release must not change with it.
+	before := s.PackageVersions(appID)
+	if _, err := c.MicroApp.ReleasePackage(ctx, bytedancetest.ComponentAppID, token); err != nil {
+		t.Fatalf("ReleasePackage returned error: %v", err)
+	}
+	if before.Audit == nil || before.Audit.HasPublish != 0 {
+		t.Errorf("versions before release changed: %+v", before.Audit)
+	}
+	after := s.PackageVersions(appID)
+	if after.Current == nil || after.Current.Version != "1.0.0" || after.Audit != nil {
+		t.Errorf("versions after release are %+v", after)
+	}
+
+	audit(t, s, c, componentToken, token, "1.1.0")
+	if _, err := c.MicroApp.ReleasePackage(ctx, bytedancetest.ComponentAppID, token); err != nil {
+		t.Fatalf("ReleasePackage returned error: %v", err)
+	}
+	versions, _, err := c.MicroApp.GetPackageVersions(ctx, bytedancetest.ComponentAppID, token)
+	if err != nil {
+		t.Fatalf("GetPackageVersions returned error: %v", err)
+	}
+	if v := versions.Current; v == nil || v.Version != "1.1.0" || v.Rollback == nil ||
+		!v.Rollback.CanRollback || v.Rollback.LastVersion != "1.0.0" {
+		t.Errorf("current version after second release is %+v", v)
+	}
+
+	if _, err := c.MicroApp.RollbackPackage(ctx, bytedancetest.ComponentAppID, token); err != nil {
+		t.Fatalf("RollbackPackage returned error: %v", err)
+	}
+	if v := s.PackageVersions(appID).Current; v == nil || v.Version != "1.0.0" || v.Rollback.CanRollback {
+		t.Errorf("current version after rollback is %+v", v)
+	}
+	if _, err := c.MicroApp.RollbackPackage(ctx, bytedancetest.ComponentAppID, token); !errors.Is(err,
+		bytedance.ErrPermissionDenied) {
+		t.Errorf("RollbackPackage without history returned error %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestServer_InjectErrNo(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, componentToken, _, _ := authorize(t, s)
+	ctx := context.Background()
+
+	s.InjectErrNo("tp.template.get_tpl_list", bytedancetest.ErrNoInvalidParameter, "ignored", 0)
+	s.InjectErrNo("tp.template.get_tpl_list", bytedancetest.ErrNoPermission, "denied", 1)
+	_, _, err := c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, componentToken)
+	if !errors.Is(err, bytedance.ErrPermissionDenied) {
+		t.Errorf("GetTemplates returned error %v, want ErrPermissionDenied", err)
+	}
+	if _, _, err := c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, componentToken); err != nil {
+		t.Errorf("GetTemplates returned error %v after injected errors were consumed", err)
+	}
+}
@@ -0,0 +1,182 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxCallbackBodySize is the max size of the body of a push.
+const maxCallbackBodySize = 1 << 20
+
+// Errors reported by CallbackHandler of rejected pushes.
+var (
+	ErrInvalidSignature = errors.New("invalid msg_signature")
+	ErrStaleTimestamp   = errors.New("timestamp of push out of max age")
+)
+
+// CallbackBody is the body of a push of the platform.
+type CallbackBody struct {
+	TimeStamp    string `json:"TimeStamp"`
+	Nonce        string `json:"Nonce"`
+	Encrypt      string `json:"Encrypt"`
+	MsgSignature string `json:"MsgSignature"`
+}
+
+// CallbackMessage is a decrypted push of the platform.
+type CallbackMessage struct {
+	MsgType string `json:"MsgType"`
+	Event   string `json:"Event"`
+
+	// AppID is the appid at the end of the decrypted message.
+	AppID string `json:"-"`
+
+	// Raw is the decrypted JSON of the message.
+	Raw json.RawMessage `json:"-"`
+}
+
+// CallbackFunc handles a decrypted push. If it returns an error the push is
+// not acknowledged, and the platform will push it again.
+type CallbackFunc func(ctx context.Context, msg *CallbackMessage) error
+
+// CallbackHandler is an http.Handler of the pushes of the platform. It verifies
+// the signature of a push, decrypts it and dispatches it to Callback, then
+// replies "success" to acknowledge it.
+//
+// Bad requests are replied with 4xx, e.g. 403 Forbidden for a wrong signature
+// or a stale timestamp, and 500 Internal Server Error if Callback fails.
+type CallbackHandler struct {
+	TPToken        string
+	EncodingAESKey string
+
+	// AppID, when not empty, rejects messages of other appid.
+	AppID string
+
+	// MaxAge, when not zero, rejects pushes whose timestamp is further than it
+	// from now, so that captured pushes can not be replayed later.
+	MaxAge time.Duration
+
+	Callback CallbackFunc
+
+	// OnError, when not nil, is called with the error of every failed push.
+	OnError func(r *http.Request, err error)
+}
+
+// NewCallbackHandler returns a CallbackHandler dispatching the pushes verified
+// by tpToken and decrypted by encodingAESKey to callback.
+func NewCallbackHandler(tpToken, encodingAESKey string, callback CallbackFunc) *CallbackHandler {
+	return &CallbackHandler{TPToken: tpToken, EncodingAESKey: encodingAESKey, Callback: callback}
+}
+
+// CallbackHandler returns a CallbackHandler of the pushes of p.
+func (p *Platform) CallbackHandler(callback CallbackFunc) *CallbackHandler {
+	return NewCallbackHandler(p.TPToken, p.EncodingAESKey, callback)
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.fail(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+
+	body, err := readCallbackBody(r)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if !Verify(h.TPToken, body.TimeStamp, body.Nonce, body.Encrypt, body.MsgSignature) {
+		h.fail(w, r, http.StatusForbidden, ErrInvalidSignature)
+		return
+	}
+	if h.MaxAge > 0 && !fresh(body.TimeStamp, h.MaxAge) {
+		h.fail(w, r, http.StatusForbidden, ErrStaleTimestamp)
+		return
+	}
+	msg, err := h.decrypt(body.Encrypt)
+	if err != nil {
+		h.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if h.Callback != nil {
+		if err := h.Callback(r.Context(), msg); err != nil {
+			h.fail(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "success")
+}
+
+// readCallbackBody reads the body of r, with timestamp, nonce and
+// msg_signature taken from the query if not in the body.
+func readCallbackBody(r *http.Request) (*CallbackBody, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCallbackBodySize {
+		return nil, errors.New("callback body too large")
+	}
+	body := new(CallbackBody)
+	if err := json.Unmarshal(data, body); err != nil {
+		return nil, fmt.Errorf("decode callback body: %w", err)
+	}
+
+	q := r.URL.Query()
+	if body.TimeStamp == "" {
+		body.TimeStamp = q.Get("timestamp")
+	}
+	if body.Nonce == "" {
+		body.Nonce = q.Get("nonce")
+	}
+	if body.MsgSignature == "" {
+		body.MsgSignature = q.Get("msg_signature")
+	}
+	if body.Encrypt == "" {
+		return nil, errors.New("callback body has no Encrypt")
+	}
+	return body, nil
+}
+
+// fresh reports whether the unix timestamp is within maxAge from now.
+func fresh(timestamp string, maxAge time.Duration) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(sec, 0))
+	return -maxAge <= age && age <= maxAge
+}
+
+// decrypt decrypts and decodes the message encrypt.
+func (h *CallbackHandler) decrypt(encrypt string) (*CallbackMessage, error) {
+	data, appID, err := DecryptMessage(h.EncodingAESKey, encrypt)
+	if err != nil {
+		return nil, err
+	}
+	if h.AppID != "" && appID != h.AppID {
+		return nil, ErrAppIDMismatch
+	}
+	msg := &CallbackMessage{AppID: appID, Raw: data}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("decode callback message: %w", err)
+	}
+	return msg, nil
+}
+
+// fail replies r with code and reports err to OnError.
+func (h *CallbackHandler) fail(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if h.OnError != nil {
+		h.OnError(r, err)
+	}
+	http.Error(w, http.StatusText(code), code)
+}
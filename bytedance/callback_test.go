@@ -0,0 +1,151 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTPToken = "tp_token"
+
+// pushBody returns the body of a push of msg signed at timestamp.
+func pushBody(t *testing.T, msg, timestamp string) string {
+	t.Helper()
+	encrypted, err := EncryptMsg(testEncodingAESKey, "tt_component", []byte(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(&CallbackBody{
+		TimeStamp:    timestamp,
+		Nonce:        "nonce",
+		Encrypt:      encrypted,
+		MsgSignature: Signature(testTPToken, timestamp, "nonce", encrypted),
+	})
+	return string(b)
+}
+
+func TestCallbackHandler(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	valid := pushBody(t, `{"MsgType":"Ticket","Ticket":"ticket"}`, now)
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		callback CallbackFunc
+		wantCode int
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			method:   http.MethodPost,
+			body:     valid,
+			wantCode: http.StatusOK,
+			wantBody: "success",
+		},
+		{
+			name:     "bad signature",
+			method:   http.MethodPost,
+			body:     strings.Replace(valid, `"MsgSignature":"`, `"MsgSignature":"0`, 1),
+			wantCode: http.StatusForbidden,
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "stale timestamp",
+			method:   http.MethodPost,
+			body:     pushBody(t, `{"MsgType":"Ticket"}`, stale),
+			wantCode: http.StatusForbidden,
+			wantErr:  ErrStaleTimestamp,
+		},
+		{
+			name:     "not POST",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "oversized body",
+			method:   http.MethodPost,
+			body:     `{"Encrypt":"` + strings.Repeat("x", maxCallbackBodySize) + `"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed body",
+			method:   http.MethodPost,
+			body:     `{`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "callback error",
+			method:   http.MethodPost,
+			body:     valid,
+			callback: func(context.Context, *CallbackMessage) error { return errors.New("boom") },
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			callback := tt.callback
+			if callback == nil {
+				callback = func(_ context.Context, msg *CallbackMessage) error {
+					called = true
+					if msg.MsgType != MsgTypeTicket || msg.AppID != "tt_component" {
+						t.Errorf("callback got message %+v", msg)
+					}
+					return nil
+				}
+			}
+			var reported error
+			h := NewCallbackHandler(testTPToken, testEncodingAESKey, callback)
+			h.MaxAge = 5 * time.Minute
+			h.OnError = func(_ *http.Request, err error) { reported = err }
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/callback", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status is %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body is %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantCode == http.StatusOK {
+				if !called {
+					t.Error("callback was not called")
+				}
+				if reported != nil {
+					t.Errorf("OnError was called with %v", reported)
+				}
+			} else {
+				if called {
+					t.Error("callback was called of a rejected push")
+				}
+				if reported == nil {
+					t.Error("OnError was not called")
+				}
+			}
+			if tt.wantErr != nil && !errors.Is(reported, tt.wantErr) {
+				t.Errorf("OnError was called with %v, want %v", reported, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallbackHandler_queryParams(t *testing.T) {
+	encrypted, _ := EncryptMsg(testEncodingAESKey, "tt_component", []byte(`{"MsgType":"Ticket"}`))
+	b, _ := json.Marshal(&CallbackBody{Encrypt: encrypted})
+	target := "/callback?timestamp=1&nonce=n&msg_signature=" + Signature(testTPToken, "1", "n", encrypted)
+
+	h := NewCallbackHandler(testTPToken, testEncodingAESKey, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(string(b))))
+	if rec.Code != http.StatusOK || rec.Body.String() != "success" {
+		t.Errorf("response is %d %q, want 200 success", rec.Code, rec.Body.String())
+	}
+}
@@ -1,7 +1,6 @@
 package bytedance
 
 import (
-	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
@@ -10,26 +9,62 @@ import (
 	"errors"
 )
 
+// ErrAppIDMismatch is returned by DecryptMessageFor if the message is not of the appid.
+var ErrAppIDMismatch = errors.New("appid of message mismatch")
+
 // DecryptMsg 消息解密
+// 消息无法解密时返回 nil，需要错误信息时使用 DecryptMessage。
 func DecryptMsg(encodeAesKey string, encryptMsg string) map[string]interface{} {
+	msgBody, _, err := DecryptMessage(encodeAesKey, encryptMsg)
+	if err != nil {
+		return nil
+	}
+	// 返回解析的消息 json 串
+	var result map[string]interface{}
+	_ = json.Unmarshal(msgBody, &result)
+	return result
+}
+
+// DecryptMessage 消息解密，返回消息体和消息末尾的 appid
+func DecryptMessage(encodeAesKey string, encryptMsg string) ([]byte, string, error) {
 	// get aes key
-	AESKey, _ := base64.StdEncoding.DecodeString(encodeAesKey + "=")
+	AESKey, err := base64.StdEncoding.DecodeString(encodeAesKey + "=")
+	if err != nil {
+		return nil, "", errors.New("invalid encoding aes key")
+	}
 
 	// decrypt msg
-	decryptMsg, _ := Decrypt(encryptMsg, string(AESKey))
+	decryptMsg, err := Decrypt(encryptMsg, string(AESKey))
+	if err != nil {
+		return nil, "", err
+	}
 
-	// plain text
+	// plain text: 随机 16 字节 + 消息长度（大端 4 字节）+ 消息体 + appid
 	plainText := []byte(decryptMsg)
-	buf := bytes.NewBuffer(plainText[16:20])
-	var length int32
-	_ = binary.Read(buf, binary.BigEndian, &length)
+	if len(plainText) < 20 {
+		return nil, "", errors.New("decrypted message too short")
+	}
+	length := binary.BigEndian.Uint32(plainText[16:20])
+	if uint64(length) > uint64(len(plainText)-20) {
+		return nil, "", errors.New("message length out of range")
+	}
 
 	// 获取正常的消息体
-	msgBody := string(plainText[20 : 20+length])
-	// 返回解析的消息 json 串
-	var result map[string]interface{}
-	_ = json.Unmarshal([]byte(msgBody), &result)
-	return result
+	msgBody := plainText[20 : 20+length]
+	appID := string(plainText[20+length:])
+	return msgBody, appID, nil
+}
+
+// DecryptMessageFor 消息解密，消息末尾的 appid 不是 appID 时返回 ErrAppIDMismatch
+func DecryptMessageFor(encodeAesKey, appID, encryptMsg string) ([]byte, error) {
+	msgBody, msgAppID, err := DecryptMessage(encodeAesKey, encryptMsg)
+	if err != nil {
+		return nil, err
+	}
+	if msgAppID != appID {
+		return nil, ErrAppIDMismatch
+	}
+	return msgBody, nil
 }
 
 // Decrypt 解密
@@ -65,14 +100,37 @@ func AESCBCDecrypt(encryptData, key []byte) ([]byte, error) {
 
 	mode := cipher.NewCBCDecrypter(block, iv)
 	mode.CryptBlocks(encryptData, encryptData)
-	encryptData = PKCS7UnPadding(encryptData)
 
-	return encryptData, nil
+	return pkcs7UnPadding(encryptData)
 }
 
+// maxPadding is the max PKCS7 padding accepted, messages may be padded to
+// blocks of 32 bytes.
+const maxPadding = 32
+
 // PKCS7UnPadding un padding
+// 填充无效时原样返回 origData.
 func PKCS7UnPadding(origData []byte) []byte {
+	data, err := pkcs7UnPadding(origData)
+	if err != nil {
+		return origData
+	}
+	return data
+}
+
+func pkcs7UnPadding(origData []byte) ([]byte, error) {
 	length := len(origData)
+	if length == 0 {
+		return nil, errors.New("invalid padding")
+	}
 	unPadding := int(origData[length-1])
-	return origData[:(length - unPadding)]
+	if unPadding == 0 || unPadding > maxPadding || unPadding > length {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range origData[length-unPadding:] {
+		if int(b) != unPadding {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return origData[:(length - unPadding)], nil
 }
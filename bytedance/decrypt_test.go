@@ -0,0 +1,115 @@
+package bytedance
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// encryptRaw encrypts data, a multiple of the block size, with the test key
+// and without padding, so that malformed plain texts can be built.
+func encryptRaw(t *testing.T, data []byte) string {
+	t.Helper()
+	key, _ := base64.StdEncoding.DecodeString(testEncodingAESKey + "=")
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, aes.BlockSize+len(data))
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], data)
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+// plainText returns the plain text of msg with length as its length field.
+func plainText(length uint32, msg, appID string) []byte {
+	b := make([]byte, 20, 20+len(msg)+len(appID))
+	binary.BigEndian.PutUint32(b[16:], length)
+	return append(append(b, msg...), appID...)
+}
+
+func TestDecryptMessage_malformed(t *testing.T) {
+	pad := func(data []byte) []byte { return PKCS7Padding(data, aes.BlockSize) }
+	valid := plainText(2, "{}", "tt_component")
+	tests := []struct {
+		name       string
+		encodedKey string
+		msg        string
+	}{
+		{"invalid base64", testEncodingAESKey, "not base64!"},
+		{"invalid encoding aes key", "short", encryptRaw(t, pad(valid))},
+		{"wrong key size", strings.TrimSuffix(base64.StdEncoding.EncodeToString([]byte("01234567890")), "="), encryptRaw(t, pad(valid))},
+		{"only iv", testEncodingAESKey, base64.StdEncoding.EncodeToString(make([]byte, aes.BlockSize))},
+		{"shorter than iv", testEncodingAESKey, base64.StdEncoding.EncodeToString(make([]byte, 8))},
+		{"partial block", testEncodingAESKey, base64.StdEncoding.EncodeToString(make([]byte, aes.BlockSize+8))},
+		{"zero padding", testEncodingAESKey, encryptRaw(t, append(valid[:16:16], make([]byte, 16)...))},
+		{"padding longer than 32", testEncodingAESKey, encryptRaw(t, bytes.Repeat([]byte{48}, 48))},
+		{"inconsistent padding", testEncodingAESKey,
+			encryptRaw(t, append(bytes.Repeat([]byte{'x'}, 28), 1, 2, 3, 4))},
+		{"short plain text", testEncodingAESKey, encryptRaw(t, pad(make([]byte, 19)))},
+		{"length out of range", testEncodingAESKey, encryptRaw(t, pad(plainText(13, "{}", "appid")))},
+		{"length overflow", testEncodingAESKey, encryptRaw(t, pad(plainText(0xffffffff, "{}", "appid")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, appID, err := DecryptMessage(tt.encodedKey, tt.msg)
+			if err == nil {
+				t.Errorf("DecryptMessage returned %q, %q, want error", msg, appID)
+			}
+			if m := DecryptMsg(tt.encodedKey, tt.msg); m != nil {
+				t.Errorf("DecryptMsg returned %v, want nil", m)
+			}
+		})
+	}
+}
+
+func TestDecryptMessage_lengthBoundary(t *testing.T) {
+	// a length covering the whole rest leaves an empty appid.
+	msg, appID, err := DecryptMessage(testEncodingAESKey,
+		encryptRaw(t, PKCS7Padding(plainText(7, "{}appid", ""), aes.BlockSize)))
+	if err != nil {
+		t.Fatalf("DecryptMessage returned error: %v", err)
+	}
+	if string(msg) != "{}appid" || appID != "" {
+		t.Errorf("DecryptMessage returned %q, %q, want %q, %q", msg, appID, "{}appid", "")
+	}
+}
+
+func TestDecryptMessageFor(t *testing.T) {
+	encrypted, err := EncryptMsg(testEncodingAESKey, "tt_component", []byte("{}"))
+	if err != nil {
+		t.Fatalf("EncryptMsg returned error: %v", err)
+	}
+	if msg, err := DecryptMessageFor(testEncodingAESKey, "tt_component", encrypted); err != nil || string(msg) != "{}" {
+		t.Errorf("DecryptMessageFor returned %q, %v, want {}", msg, err)
+	}
+	if _, err := DecryptMessageFor(testEncodingAESKey, "tt_other", encrypted); !errors.Is(err, ErrAppIDMismatch) {
+		t.Errorf("DecryptMessageFor returned error %v, want ErrAppIDMismatch", err)
+	}
+}
+
+func TestPKCS7UnPadding(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"nil", nil, nil},
+		{"padded", []byte{'a', 'b', 2, 2}, []byte{'a', 'b'}},
+		{"full block of padding", bytes.Repeat([]byte{16}, 16), []byte{}},
+		{"zero padding kept", []byte{'a', 0}, []byte{'a', 0}},
+		{"padding longer than data kept", []byte{'a', 3}, []byte{'a', 3}},
+		{"inconsistent padding kept", []byte{'a', 1, 2}, []byte{'a', 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PKCS7UnPadding(tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("PKCS7UnPadding returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
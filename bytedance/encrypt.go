@@ -0,0 +1,72 @@
+package bytedance
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+)
+
+// EncryptMsg 消息加密
+// 加密 msg 为 DecryptMsg 可解密的消息：随机 16 字节 + 消息长度（大端 4 字节）+ msg + appID，
+// 经 PKCS7 填充后 AES-CBC 加密，再 base64 编码。
+func EncryptMsg(encodeAesKey, appID string, msg []byte) (string, error) {
+	// get aes key
+	AESKey, err := base64.StdEncoding.DecodeString(encodeAesKey + "=")
+	if err != nil {
+		return "", err
+	}
+
+	// plain text
+	var buf bytes.Buffer
+	buf.Grow(20 + len(msg) + len(appID))
+	if _, err := io.CopyN(&buf, rand.Reader, 16); err != nil {
+		return "", err
+	}
+	_ = binary.Write(&buf, binary.BigEndian, int32(len(msg)))
+	buf.Write(msg)
+	buf.WriteString(appID)
+
+	return Encrypt(buf.String(), string(AESKey))
+}
+
+// Encrypt 加密
+func Encrypt(rawData, key string) (string, error) {
+	data, err := AESCBCEncrypt([]byte(rawData), []byte(key))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// AESCBCEncrypt aes cbc 加密，随机 iv 置于密文之前
+func AESCBCEncrypt(data, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := block.BlockSize()
+	data = PKCS7Padding(data, blockSize)
+	encryptData := make([]byte, blockSize+len(data))
+	iv := encryptData[:blockSize]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	mode := cipher.NewCBCEncrypter(block, iv)
+	mode.CryptBlocks(encryptData[blockSize:], data)
+
+	return encryptData, nil
+}
+
+// PKCS7Padding padding
+func PKCS7Padding(origData []byte, blockSize int) []byte {
+	padding := blockSize - len(origData)%blockSize
+	padded := make([]byte, len(origData), len(origData)+padding)
+	copy(padded, origData)
+	return append(padded, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
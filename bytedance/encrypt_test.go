@@ -0,0 +1,88 @@
+package bytedance
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testEncodingAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+
+func TestEncryptMsg_roundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		appID string
+	}{
+		{"empty message", "", "tt_component"},
+		{"one block message", "0123456789abcdef", "tt_component"},
+		{"multi-block message", `{"MsgType":"Ticket","Ticket":"` + strings.Repeat("t", 100) + `"}`, "tt_component"},
+		{"non-ASCII appid", `{"Event":"authorized"}`, "第三方平台"},
+		{"non-ASCII message", `{"Reason":"名称违规"}`, "tt_组件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := EncryptMsg(testEncodingAESKey, tt.appID, []byte(tt.msg))
+			if err != nil {
+				t.Fatalf("EncryptMsg returned error: %v", err)
+			}
+			msg, appID, err := DecryptMessage(testEncodingAESKey, encrypted)
+			if err != nil {
+				t.Fatalf("DecryptMessage returned error: %v", err)
+			}
+			if !bytes.Equal(msg, []byte(tt.msg)) {
+				t.Errorf("DecryptMessage returned message %q, want %q", msg, tt.msg)
+			}
+			if appID != tt.appID {
+				t.Errorf("DecryptMessage returned appid %q, want %q", appID, tt.appID)
+			}
+		})
+	}
+}
+
+func TestEncryptMsg_DecryptMsg(t *testing.T) {
+	want := map[string]interface{}{"MsgType": "Ticket", "Ticket": "ticket+/="}
+	encrypted, err := EncryptMsg(testEncodingAESKey, "tt_component", []byte(`{"MsgType":"Ticket","Ticket":"ticket+/="}`))
+	if err != nil {
+		t.Fatalf("EncryptMsg returned error: %v", err)
+	}
+	if got := DecryptMsg(testEncodingAESKey, encrypted); !reflect.DeepEqual(got, want) {
+		t.Errorf("DecryptMsg returned %v, want %v", got, want)
+	}
+}
+
+func TestEncryptMsg_randomized(t *testing.T) {
+	a, _ := EncryptMsg(testEncodingAESKey, "tt_component", []byte("msg"))
+	b, _ := EncryptMsg(testEncodingAESKey, "tt_component", []byte("msg"))
+	if a == b {
+		t.Error("EncryptMsg returned the same ciphertext twice")
+	}
+}
+
+func TestPKCS7Padding(t *testing.T) {
+	for n := 0; n <= 33; n++ {
+		data := bytes.Repeat([]byte{'x'}, n)
+		padded := PKCS7Padding(data, 16)
+		if len(padded)%16 != 0 || len(padded) <= n {
+			t.Errorf("PKCS7Padding of %d bytes returned %d bytes", n, len(padded))
+		}
+		if got := PKCS7UnPadding(padded); !bytes.Equal(got, data) {
+			t.Errorf("PKCS7UnPadding(PKCS7Padding(%d bytes)) returned %d bytes", n, len(got))
+		}
+	}
+}
+
+func TestSignature_Verify(t *testing.T) {
+	signature := Signature("tp_token", "1609459200", "nonce", "encrypted")
+	if !Verify("tp_token", "1609459200", "nonce", "encrypted", signature) {
+		t.Error("Verify returned false of the signature by Signature")
+	}
+	if Verify("tp_token", "1609459201", "nonce", "encrypted", signature) {
+		t.Error("Verify returned true of another timestamp")
+	}
+	// values are sorted, their order does not matter.
+	if Signature("b", "a", "c", "d") != Signature("d", "c", "b", "a") {
+		t.Error("Signature depends on the order of values")
+	}
+}
@@ -0,0 +1,80 @@
+package bytedance
+
+import (
+	"errors"
+	"sync"
+)
+
+// Sentinel errors of the documented errno values, an *ErrorResponse matches
+// them with errors.Is, e.g. errors.Is(err, bytedance.ErrTokenExpired).
+var (
+	ErrInvalidParameter = errors.New("invalid parameter")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrQuotaExhausted   = errors.New("quota exhausted")
+	ErrServerBusy       = errors.New("server busy")
+)
+
+var (
+	errNoMu sync.RWMutex
+
+	// errNoErrors maps errno to sentinel error.
+	errNoErrors = map[int]error{
+		-1:    ErrServerBusy,       // 系统繁忙
+		40001: ErrInvalidParameter, // 参数错误
+		40002: ErrInvalidToken,     // component_access_token 无效
+		40003: ErrTokenExpired,     // component_access_token 过期
+		40004: ErrInvalidToken,     // authorizer_access_token 无效
+		40005: ErrTokenExpired,     // authorizer_access_token 过期
+		40006: ErrPermissionDenied, // 没有权限
+		40007: ErrRateLimited,      // 调用频率超过限制
+		40008: ErrQuotaExhausted,   // 调用次数超过限额
+		50000: ErrServerBusy,       // 服务内部错误
+	}
+)
+
+// RegisterErrNo makes an *ErrorResponse with errNo match target with errors.Is.
+// It's used for errno values not in the built-in catalog.
+func RegisterErrNo(errNo int, target error) {
+	errNoMu.Lock()
+	defer errNoMu.Unlock()
+	errNoErrors[errNo] = target
+}
+
+// ErrNoError returns the sentinel error of errNo, or nil if it's unknown.
+func ErrNoError(errNo int) error {
+	errNoMu.RLock()
+	defer errNoMu.RUnlock()
+	return errNoErrors[errNo]
+}
+
+// Is reports whether the errno of r is documented as target.
+func (r *ErrorResponse) Is(target error) bool {
+	err := ErrNoError(r.ErrNo)
+	return err != nil && err == target
+}
+
+// IsRetryable reports whether err is a transient server side error,
+// the same request may succeed later.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrServerBusy) || errors.Is(err, ErrRateLimited)
+}
+
+// IsAuthError reports whether err is caused by the token or permission of the call.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrPermissionDenied)
+}
+
+// IsTokenError reports whether err is caused by an invalid or expired token,
+// which can be fixed by fetching a new one.
+func IsTokenError(err error) bool {
+	return errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired)
+}
+
+// IsQuotaError reports whether err is caused by call frequency or quota limits.
+func IsQuotaError(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrQuotaExhausted)
+}
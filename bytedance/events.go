@@ -0,0 +1,165 @@
+package bytedance
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MsgType of pushes.
+const (
+	MsgTypeTicket = "Ticket" // component_ticket 推送
+	MsgTypeEvent  = "Event"  // 事件推送
+)
+
+// Event of pushes of MsgTypeEvent.
+const (
+	EventAuthorized       = "authorized"         // 授权成功
+	EventUpdateAuthorized = "updateauthorized"   // 更新授权
+	EventUnauthorized     = "unauthorized"       // 取消授权
+	EventPackageAudit     = "PACKAGE_AUDIT"      // 代码审核结果
+	EventModifyAppName    = "MODIFY_APP_NAME"    // 名称审核结果
+	EventModifyAppIcon    = "MODIFY_APP_ICON"    // 图标审核结果
+	EventModifyAppIntro   = "MODIFY_APP_INTRO"   // 简介审核结果
+	EventAppCategoryAudit = "APP_CATEGORY_AUDIT" // 服务类目审核结果
+)
+
+// Event is a typed push of the platform, see DecodeEvent.
+type Event interface {
+	Header() *EventHeader
+}
+
+// EventHeader is the fields common to every push.
+type EventHeader struct {
+	MsgType      string `json:"MsgType"`
+	Event        string `json:"Event,omitempty"`
+	FromUserName string `json:"FromUserName,omitempty"`
+	AppID        string `json:"AppId,omitempty"` // 授权小程序 appid
+	CreateTime   int64  `json:"CreateTime,omitempty"`
+}
+
+// Header implements Event interface.
+func (h *EventHeader) Header() *EventHeader {
+	return h
+}
+
+// Type returns the type of the push, the Event or else the MsgType.
+func (h *EventHeader) Type() string {
+	return EventType(h.MsgType, h.Event)
+}
+
+// EventType returns the type of a push of msgType and event, it's event, or
+// msgType if event is empty, e.g. MsgTypeTicket or EventAuthorized.
+func EventType(msgType, event string) string {
+	if event != "" {
+		return event
+	}
+	return msgType
+}
+
+// ComponentTicketEvent 推送 component_ticket
+type ComponentTicketEvent struct {
+	EventHeader
+	Ticket string `json:"Ticket"`
+}
+
+// AuthorizedEvent 授权成功
+type AuthorizedEvent struct {
+	EventHeader
+	AuthorizationCode          string `json:"AuthorizationCode"`
+	AuthorizationCodeExpiresIn int64  `json:"AuthorizationCodeExpiresIn"`
+}
+
+// UpdateAuthorizedEvent 更新授权
+type UpdateAuthorizedEvent struct {
+	EventHeader
+	AuthorizationCode          string `json:"AuthorizationCode"`
+	AuthorizationCodeExpiresIn int64  `json:"AuthorizationCodeExpiresIn"`
+}
+
+// UnauthorizedEvent 取消授权
+type UnauthorizedEvent struct {
+	EventHeader
+}
+
+// AuditResult is the result of an audit.
+type AuditResult struct {
+	Status int    `json:"Status"` // 审核状态
+	Reason string `json:"Reason,omitempty"`
+}
+
+// PackageAuditEvent 代码审核结果
+type PackageAuditEvent struct {
+	EventHeader
+	Content AuditResult `json:"Content"`
+}
+
+// InfoAuditEvent 名称、图标、简介审核结果，由 Event 区分
+type InfoAuditEvent struct {
+	EventHeader
+	Content AuditResult `json:"Content"`
+}
+
+// CategoryAuditEvent 服务类目审核结果
+type CategoryAuditEvent struct {
+	EventHeader
+	Content AuditResult `json:"Content"`
+}
+
+// UnknownEvent is a push without registered type, Raw is its JSON.
+type UnknownEvent struct {
+	EventHeader
+	Raw json.RawMessage `json:"-"`
+}
+
+var (
+	eventTypesMu sync.RWMutex
+
+	// eventTypes maps type of push to constructor of its Event.
+	eventTypes = map[string]func() Event{
+		MsgTypeTicket:         func() Event { return new(ComponentTicketEvent) },
+		EventAuthorized:       func() Event { return new(AuthorizedEvent) },
+		EventUpdateAuthorized: func() Event { return new(UpdateAuthorizedEvent) },
+		EventUnauthorized:     func() Event { return new(UnauthorizedEvent) },
+		EventPackageAudit:     func() Event { return new(PackageAuditEvent) },
+		EventModifyAppName:    func() Event { return new(InfoAuditEvent) },
+		EventModifyAppIcon:    func() Event { return new(InfoAuditEvent) },
+		EventModifyAppIntro:   func() Event { return new(InfoAuditEvent) },
+		EventAppCategoryAudit: func() Event { return new(CategoryAuditEvent) },
+	}
+)
+
+// RegisterEvent makes DecodeEvent decode pushes of eventType, see EventType,
+// into the Event returned by newEvent. It's used for pushes not in the
+// built-in types.
+func RegisterEvent(eventType string, newEvent func() Event) {
+	eventTypesMu.Lock()
+	defer eventTypesMu.Unlock()
+	eventTypes[eventType] = newEvent
+}
+
+// DecodeEvent decodes the decrypted push data into the Event of its type, or
+// into an *UnknownEvent if the type is not registered.
+func DecodeEvent(data []byte) (Event, error) {
+	var header EventHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	eventTypesMu.RLock()
+	newEvent, ok := eventTypes[header.Type()]
+	eventTypesMu.RUnlock()
+	if !ok {
+		return &UnknownEvent{EventHeader: header, Raw: data}, nil
+	}
+
+	event := newEvent()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Decode decodes msg into the Event of its type, see DecodeEvent.
+func (m *CallbackMessage) Decode() (Event, error) {
+	return DecodeEvent(m.Raw)
+}
@@ -0,0 +1,121 @@
+package bytedance
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	header := func(event string) EventHeader {
+		return EventHeader{MsgType: MsgTypeEvent, Event: event, FromUserName: "user", AppID: "tt_app", CreateTime: 1600000000}
+	}
+	common := `"FromUserName":"user","AppId":"tt_app","CreateTime":1600000000`
+	tests := []struct {
+		name string
+		data string
+		want Event
+	}{
+		{
+			name: "component ticket",
+			data: `{"MsgType":"Ticket","Ticket":"ticket","CreateTime":1600000000}`,
+			want: &ComponentTicketEvent{EventHeader: EventHeader{MsgType: MsgTypeTicket, CreateTime: 1600000000}, Ticket: "ticket"},
+		},
+		{
+			name: "authorized",
+			data: `{"MsgType":"Event","Event":"authorized",` + common +
+				`,"AuthorizationCode":"code","AuthorizationCodeExpiresIn":600}`,
+			want: &AuthorizedEvent{EventHeader: header(EventAuthorized), AuthorizationCode: "code",
+				AuthorizationCodeExpiresIn: 600},
+		},
+		{
+			name: "update authorized",
+			data: `{"MsgType":"Event","Event":"updateauthorized",` + common +
+				`,"AuthorizationCode":"code","AuthorizationCodeExpiresIn":600}`,
+			want: &UpdateAuthorizedEvent{EventHeader: header(EventUpdateAuthorized), AuthorizationCode: "code",
+				AuthorizationCodeExpiresIn: 600},
+		},
+		{
+			name: "unauthorized",
+			data: `{"MsgType":"Event","Event":"unauthorized",` + common + `}`,
+			want: &UnauthorizedEvent{EventHeader: header(EventUnauthorized)},
+		},
+		{
+			name: "package audit",
+			data: `{"MsgType":"Event","Event":"PACKAGE_AUDIT",` + common + `,"Content":{"Status":1}}`,
+			want: &PackageAuditEvent{EventHeader: header(EventPackageAudit), Content: AuditResult{Status: 1}},
+		},
+		{
+			name: "app name audit",
+			data: `{"MsgType":"Event","Event":"MODIFY_APP_NAME",` + common + `,"Content":{"Status":2,"Reason":"名称违规"}}`,
+			want: &InfoAuditEvent{EventHeader: header(EventModifyAppName), Content: AuditResult{Status: 2, Reason: "名称违规"}},
+		},
+		{
+			name: "app icon audit",
+			data: `{"MsgType":"Event","Event":"MODIFY_APP_ICON",` + common + `,"Content":{"Status":1}}`,
+			want: &InfoAuditEvent{EventHeader: header(EventModifyAppIcon), Content: AuditResult{Status: 1}},
+		},
+		{
+			name: "app intro audit",
+			data: `{"MsgType":"Event","Event":"MODIFY_APP_INTRO",` + common + `,"Content":{"Status":1}}`,
+			want: &InfoAuditEvent{EventHeader: header(EventModifyAppIntro), Content: AuditResult{Status: 1}},
+		},
+		{
+			name: "category audit",
+			data: `{"MsgType":"Event","Event":"APP_CATEGORY_AUDIT",` + common + `,"Content":{"Status":1}}`,
+			want: &CategoryAuditEvent{EventHeader: header(EventAppCategoryAudit), Content: AuditResult{Status: 1}},
+		},
+		{
+			name: "unknown event",
+			data: `{"MsgType":"Event","Event":"NEW_EVENT",` + common + `,"Extra":{"a":1}}`,
+			want: &UnknownEvent{EventHeader: header("NEW_EVENT"),
+				Raw: json.RawMessage(`{"MsgType":"Event","Event":"NEW_EVENT",` + common + `,"Extra":{"a":1}}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeEvent([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("DecodeEvent returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeEvent returned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEvent_invalid(t *testing.T) {
+	for _, data := range []string{``, `{`, `{"MsgType":"Event","Event":"PACKAGE_AUDIT","Content":"passed"}`} {
+		if event, err := DecodeEvent([]byte(data)); err == nil {
+			t.Errorf("DecodeEvent(%q) returned %+v, want error", data, event)
+		}
+	}
+}
+
+// customEvent is a push type registered by a test.
+type customEvent struct {
+	EventHeader
+	Value string `json:"Value"`
+}
+
+func TestRegisterEvent(t *testing.T) {
+	const eventType = "TEST_CUSTOM_EVENT"
+	RegisterEvent(eventType, func() Event { return new(customEvent) })
+	t.Cleanup(func() {
+		eventTypesMu.Lock()
+		delete(eventTypes, eventType)
+		eventTypesMu.Unlock()
+	})
+
+	msg := &CallbackMessage{MsgType: MsgTypeEvent, Event: eventType,
+		Raw: json.RawMessage(`{"MsgType":"Event","Event":"TEST_CUSTOM_EVENT","Value":"v"}`)}
+	got, err := msg.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	want := &customEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: eventType}, Value: "v"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode returned %+v, want %+v", got, want)
+	}
+}
@@ -2,9 +2,12 @@ package bytedance
 
 import (
 	"context"
-	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 )
 
@@ -21,9 +24,11 @@ func (f File) Read(p []byte) (n int, err error) {
 
 // DownloadWebViewFile 下载域名校验文件
 func (s *ThirdPartyService) DownloadWebViewFile(ctx context.Context, componentAppID, componentAccessToken string,
-	body *DeleteTemplateRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/tp/download/webview_file?component_appid=%v&component_access_token=%v",
-		componentAccessToken, componentAppID)
+	body *DeleteTemplateRequest) (*Response, error) {
+	u := withQuery("v1/tp/download/webview_file", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, body)
 	if err != nil {
@@ -32,6 +37,57 @@ func (s *ThirdPartyService) DownloadWebViewFile(ctx context.Context, componentAp
 	return s.client.Do(ctx, req, nil)
 }
 
+// DownloadWebViewFileTo 下载域名校验文件，写入 w
+// The file is streamed to w without being buffered, an errno response is
+// returned as *ErrorResponse.
+func (s *ThirdPartyService) DownloadWebViewFileTo(ctx context.Context, componentAppID, componentAccessToken string,
+	w io.Writer) (*Response, error) {
+	u := withQuery("v1/tp/download/webview_file", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
+
+	req, err := s.client.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, w)
+}
+
+// DownloadWebViewFileToFile 下载域名校验文件，保存到 path
+// The file is replaced only if it's wholly downloaded.
+func (s *ThirdPartyService) DownloadWebViewFileToFile(ctx context.Context, componentAppID,
+	componentAccessToken, path string) (*Response, error) {
+	return downloadToFile(path, func(w io.Writer) (*Response, error) {
+		return s.DownloadWebViewFileTo(ctx, componentAppID, componentAccessToken, w)
+	})
+}
+
+// downloadToFile downloads into a temporary file by download, and renames it
+// to path on success. The file is saved with mode 0644.
+func downloadToFile(path string, download func(w io.Writer) (*Response, error)) (*Response, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name()
+	resp, err := download(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// temporary files are created with mode 0600.
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return resp, err
+}
+
 // UploadPicMaterialRequest 上传图片请求
 type UploadPicMaterialRequest struct {
 	MaterialType int   `json:"material_type"`
@@ -56,9 +112,11 @@ func (u UploadPicMaterialRequest) MultipartParams() map[string]io.Reader {
 // 使用修改名称、图标、服务类目等涉及材料证明的接口前，都需要先使用这个图片上传接口拿到返回的图片地址
 // 目前只支持bmp、jpeg、jpg、png格式。
 func (s *ThirdPartyService) UploadPicMaterial(ctx context.Context, componentAppID, componentAccessToken string,
-	body *UploadPicMaterialRequest) (string, *http.Response, error) {
-	u := fmt.Sprintf("v1/tp/upload_pic_material?component_appid=%v&component_access_token=%v",
-		componentAccessToken, componentAppID)
+	body *UploadPicMaterialRequest) (string, *Response, error) {
+	u := withQuery("v1/tp/upload_pic_material", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 	var address string
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -0,0 +1,154 @@
+package bytedance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\nfake image")
+
+// setupDownload returns a client of a server replying an image, a JSON error
+// envelope if the access token is "invalid", or an HTML error page of a
+// gateway if it's "gateway".
+func setupDownload(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get(ParamAuthorizerAccessToken) == "gateway" || q.Get(ParamComponentAccessToken) == "gateway" {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+			return
+		}
+		if q.Get(ParamAuthorizerAccessToken) == "invalid" || q.Get(ParamComponentAccessToken) == "invalid" {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"errno":40004,"message":"invalid authorizer_access_token"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngData)
+	}), opts...)
+}
+
+func TestDownloadQrcodeTo(t *testing.T) {
+	c := setupDownload(t)
+	body := &DownloadQrcodeRequest{Version: "current"}
+
+	var buf bytes.Buffer
+	if _, err := c.MicroApp.DownloadQrcodeTo(context.Background(), "appid", "token", body, &buf); err != nil {
+		t.Fatalf("DownloadQrcodeTo returned error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), pngData) {
+		t.Errorf("DownloadQrcodeTo wrote %q, want %q", buf.Bytes(), pngData)
+	}
+
+	buf.Reset()
+	_, err := c.MicroApp.DownloadQrcodeTo(context.Background(), "appid", "invalid", body, &buf)
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.ErrNo != 40004 {
+		t.Errorf("DownloadQrcodeTo returned error %v, want *ErrorResponse of errno 40004", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("DownloadQrcodeTo wrote %q of a JSON error", buf.Bytes())
+	}
+}
+
+func TestDownloadQrcodeTo_errorStatus(t *testing.T) {
+	c := setupDownload(t)
+	var buf bytes.Buffer
+	resp, err := c.MicroApp.DownloadQrcodeTo(context.Background(), "appid", "gateway",
+		&DownloadQrcodeRequest{Version: "current"}, &buf)
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusBadGateway {
+		t.Errorf("DownloadQrcodeTo returned error %v, want *ErrorResponse of status 502", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("DownloadQrcodeTo returned response %+v, want status 502", resp)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("DownloadQrcodeTo wrote %q of an error page", buf.Bytes())
+	}
+
+	// the error page is read to be retried.
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	c = setupDownload(t, WithRetryPolicy(policy))
+	resp, err = c.MicroApp.DownloadQrcodeTo(WithIdempotent(context.Background()), "appid", "gateway",
+		&DownloadQrcodeRequest{Version: "current"}, &buf)
+	if err == nil || resp.Attempts != 3 || buf.Len() != 0 {
+		t.Errorf("DownloadQrcodeTo returned %v after %d attempts and wrote %q, want error after 3",
+			err, resp.Attempts, buf.Bytes())
+	}
+}
+
+func TestDownloadWebViewFileToFile(t *testing.T) {
+	c := setupDownload(t)
+	dir, err := ioutil.TempDir("", "bytedance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "webview.txt")
+
+	if _, err := c.ThirdParty.DownloadWebViewFileToFile(context.Background(), "appid", "token", path); err != nil {
+		t.Fatalf("DownloadWebViewFileToFile returned error: %v", err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pngData) {
+		t.Errorf("saved file is %q, want %q", got, pngData)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("saved file mode is %v, want %v", info.Mode().Perm(), os.FileMode(0644))
+	}
+
+	// a failed download leaves the saved file untouched, without temporary files.
+	if _, err := c.ThirdParty.DownloadWebViewFileToFile(context.Background(), "appid", "invalid", path); err == nil {
+		t.Fatal("DownloadWebViewFileToFile returned no error")
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, pngData) {
+		t.Errorf("saved file is %q after failed download, want %q", got, pngData)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("dir has %d files after failed download, want 1", len(files))
+	}
+
+	// so does an error page.
+	if _, err := c.ThirdParty.DownloadWebViewFileToFile(context.Background(), "appid", "gateway", path); err == nil {
+		t.Fatal("DownloadWebViewFileToFile of an error page returned no error")
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, pngData) {
+		t.Errorf("saved file is %q after an error page, want %q", got, pngData)
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"text/json", true},
+		{"application/problem+json", true},
+		{"image/png", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{"Content-Type": {tt.contentType}}
+		if got := isJSON(header); got != tt.want {
+			t.Errorf("isJSON(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bytedance
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Call is a single attempt of an API call passing through interceptors.
+type Call struct {
+	// Endpoint is the logical name of the endpoint, e.g. "microapp.package.upload".
+	Endpoint string
+
+	// Request is the outgoing request, an interceptor may replace it before
+	// invoking the next one.
+	Request *http.Request
+
+	// Response is the parsed response, set once the call is invoked. An
+	// interceptor may set it without invoking the next one to short-circuit
+	// the call, a non-zero ErrNo is then reported as an *ErrorResponse.
+	Response *Response
+
+	// Duration is the time spent sending the request and reading the response.
+	Duration time.Duration
+}
+
+// Invoker invokes call, it's the rest of the interceptor chain.
+type Invoker func(ctx context.Context, call *Call) error
+
+// Interceptor intercepts every attempt of the API calls of a Client. It may
+// inspect or modify call, and invoke next to continue the call, or return
+// without invoking it to short-circuit the call.
+type Interceptor func(ctx context.Context, call *Call, next Invoker) error
+
+// WithInterceptors appends interceptors to the client, the first one is the
+// outermost.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *Client) {
+		c.Interceptors = append(c.Interceptors, interceptors...)
+	}
+}
+
+// invoke runs call through the interceptors of c and then sends it.
+func (c *Client) invoke(ctx context.Context, call *Call) error {
+	return chainInterceptors(c.Interceptors, c.roundTrip)(ctx, call)
+}
+
+func chainInterceptors(interceptors []Interceptor, final Invoker) Invoker {
+	next := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, rest := interceptors[i], next
+		next = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, rest)
+		}
+	}
+	return next
+}
+
+// roundTrip sends the request of call and parses its response.
+func (c *Client) roundTrip(ctx context.Context, call *Call) error {
+	start := time.Now()
+	resp, err := c.client.Do(call.Request)
+	call.Duration = time.Since(start)
+	if err != nil {
+		// If we got an error, and the context has been canceled,
+		// the context's error id probably more useful.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		// the URL of the error contains tokens and secrets.
+		if urlErr, ok := err.(*url.Error); ok {
+			return &url.Error{Op: urlErr.Op, URL: RedactURL(call.Request.URL), Err: urlErr.Err}
+		}
+		return err
+	}
+
+	if streaming, _ := ctx.Value(streamKey{}).(bool); streaming && !isJSON(resp.Header) && isSuccess(resp) {
+		// leave binary body, e.g. an image, to be streamed by Do, error
+		// pages are read as other responses.
+		call.Response = &Response{Response: resp}
+		return nil
+	}
+	call.Response, err = newResponse(resp)
+	return err
+}
+
+// streamKey marks the context of a call whose response body is streamed to an io.Writer.
+type streamKey struct{}
+
+// isSuccess reports whether the status code of resp is 2xx.
+func isSuccess(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// isJSON reports whether the Content-Type of header is JSON.
+func isJSON(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,108 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// setupCounting returns a client of opts talking to a test server replying
+// success, and the number of requests the server received.
+func setupCounting(t *testing.T, opts ...Option) (*Client, *int32) {
+	t.Helper()
+	var hits int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errno":0,"message":"success","data":{"path":"` + r.URL.Path + `"}}`))
+	}), opts...)
+	return c, &hits
+}
+
+func TestWithInterceptors_order(t *testing.T) {
+	var trace []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, call *Call, next Invoker) error {
+			trace = append(trace, name+" in "+call.Endpoint)
+			err := next(ctx, call)
+			trace = append(trace, name+" out")
+			return err
+		}
+	}
+	c, _ := setupCounting(t, WithInterceptors(record("first")), WithInterceptors(record("second")))
+
+	req, _ := c.NewRequest(http.MethodGet, "v1/microapp/app/info", nil)
+	if _, err := c.Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	want := []string{"first in microapp.app.info", "second in microapp.app.info", "second out", "first out"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("interceptors ran as %v, want %v", trace, want)
+	}
+}
+
+func TestInterceptor_shortCircuit(t *testing.T) {
+	var errNo int
+	cached := func(ctx context.Context, call *Call, next Invoker) error {
+		call.Response = &Response{ErrNo: errNo, Message: "made up", Data: json.RawMessage(`{"path":"cached"}`)}
+		return nil
+	}
+	c, hits := setupCounting(t, WithInterceptors(cached))
+
+	var got struct{ Path string }
+	req, _ := c.NewRequest(http.MethodGet, "v1/microapp/app/info", nil)
+	resp, err := c.Do(context.Background(), req, &got)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if got.Path != "cached" || resp.StatusCode != http.StatusOK {
+		t.Errorf("Do decoded %+v with status %d, want the made up response", got, resp.StatusCode)
+	}
+
+	// a made up errno is an API error.
+	errNo = 40006
+	req, _ = c.NewRequest(http.MethodGet, "v1/microapp/app/info", nil)
+	_, err = c.Do(context.Background(), req, nil)
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.ErrNo != 40006 || errResp.Message != "made up" {
+		t.Errorf("Do returned error %v, want *ErrorResponse of errno 40006", err)
+	}
+	if n := atomic.LoadInt32(hits); n != 0 {
+		t.Errorf("server received %d requests, want 0", n)
+	}
+}
+
+func TestInterceptor_replaceRequest(t *testing.T) {
+	var sent string
+	rewrite := func(ctx context.Context, call *Call, next Invoker) error {
+		req := call.Request.Clone(ctx)
+		req.URL.Path = "/v2/microapp/app/info"
+		q := req.URL.Query()
+		q.Set("extra", "1")
+		req.URL.RawQuery = q.Encode()
+		call.Request = req
+		err := next(ctx, call)
+		sent = call.Response.Request.URL.RawQuery
+		return err
+	}
+	c, _ := setupCounting(t, WithInterceptors(rewrite))
+
+	var got struct{ Path string }
+	req, _ := c.NewRequest(http.MethodGet, "v1/microapp/app/info?appid=a", nil)
+	if _, err := c.Do(context.Background(), req, &got); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if got.Path != "/v2/microapp/app/info" {
+		t.Errorf("server received path %v, want %v", got.Path, "/v2/microapp/app/info")
+	}
+	if want := "appid=a&extra=1"; sent != want {
+		t.Errorf("server received query %v, want %v", sent, want)
+	}
+	if req.URL.Path != "/v1/microapp/app/info" {
+		t.Errorf("request of the caller is changed to %v", req.URL)
+	}
+}
@@ -0,0 +1,135 @@
+package bytedance
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricsCollector is called after every API call of a Client.
+type MetricsCollector interface {
+	// ObserveRequest records a call to endpoint, e.g. "microapp.package.upload",
+	// which took duration and ended with the HTTP statusCode and errNo.
+	// statusCode is zero if no response was received.
+	ObserveRequest(endpoint string, duration time.Duration, statusCode, errNo int)
+}
+
+// WithMetrics sets the MetricsCollector of the client.
+func WithMetrics(metrics MetricsCollector) Option {
+	return func(c *Client) {
+		c.Metrics = metrics
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds of latency histogram buckets used
+// by NewMemoryMetrics if none are given.
+var DefaultLatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// HistogramBucket is a cumulative latency histogram bucket.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// EndpointMetrics are the metrics of an endpoint.
+type EndpointMetrics struct {
+	Count int64
+	Sum   time.Duration
+
+	// Buckets are cumulative, calls slower than every bound are only in Count.
+	Buckets []HistogramBucket
+
+	StatusCodes map[int]int64
+	ErrNos      map[int]int64
+}
+
+// MemoryMetrics is a MetricsCollector keeping per endpoint latency histograms
+// and errno counts in memory.
+type MemoryMetrics struct {
+	bounds []time.Duration
+
+	mu        sync.Mutex
+	endpoints map[string]*EndpointMetrics
+}
+
+// NewMemoryMetrics returns a MemoryMetrics with the upper bounds of latency
+// buckets, DefaultLatencyBuckets if none are given.
+func NewMemoryMetrics(buckets ...time.Duration) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	bounds := append([]time.Duration(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	return &MemoryMetrics{bounds: bounds, endpoints: make(map[string]*EndpointMetrics)}
+}
+
+// ObserveRequest implements MetricsCollector interface.
+func (m *MemoryMetrics) ObserveRequest(endpoint string, duration time.Duration, statusCode, errNo int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.endpoints[endpoint]
+	if !ok {
+		e = &EndpointMetrics{
+			Buckets:     make([]HistogramBucket, len(m.bounds)),
+			StatusCodes: make(map[int]int64),
+			ErrNos:      make(map[int]int64),
+		}
+		for i, bound := range m.bounds {
+			e.Buckets[i].UpperBound = bound
+		}
+		m.endpoints[endpoint] = e
+	}
+
+	e.Count++
+	e.Sum += duration
+	for i := range e.Buckets {
+		if duration <= e.Buckets[i].UpperBound {
+			e.Buckets[i].Count++
+		}
+	}
+	e.StatusCodes[statusCode]++
+	e.ErrNos[errNo]++
+}
+
+// Snapshot returns a copy of the metrics of every endpoint.
+func (m *MemoryMetrics) Snapshot() map[string]EndpointMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]EndpointMetrics, len(m.endpoints))
+	for endpoint, e := range m.endpoints {
+		c := EndpointMetrics{
+			Count:       e.Count,
+			Sum:         e.Sum,
+			Buckets:     append([]HistogramBucket(nil), e.Buckets...),
+			StatusCodes: make(map[int]int64, len(e.StatusCodes)),
+			ErrNos:      make(map[int]int64, len(e.ErrNos)),
+		}
+		for k, v := range e.StatusCodes {
+			c.StatusCodes[k] = v
+		}
+		for k, v := range e.ErrNos {
+			c.ErrNos[k] = v
+		}
+		snapshot[endpoint] = c
+	}
+	return snapshot
+}
+
+// Reset drops all metrics.
+func (m *MemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endpoints = make(map[string]*EndpointMetrics)
+}
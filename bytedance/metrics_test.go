@@ -0,0 +1,91 @@
+package bytedance
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryMetrics_ObserveRequest(t *testing.T) {
+	m := NewMemoryMetrics(100*time.Millisecond, 10*time.Millisecond, time.Second)
+	m.ObserveRequest("microapp.app.info", 5*time.Millisecond, http.StatusOK, 0)
+	m.ObserveRequest("microapp.app.info", 10*time.Millisecond, http.StatusOK, 40002)
+	m.ObserveRequest("microapp.app.info", 50*time.Millisecond, http.StatusBadGateway, 0)
+	m.ObserveRequest("microapp.app.info", 2*time.Second, 0, 0)
+	m.ObserveRequest("tp.template.get_tpl_list", time.Second, http.StatusOK, 0)
+
+	want := map[string]EndpointMetrics{
+		"microapp.app.info": {
+			Count: 4,
+			Sum:   2065 * time.Millisecond,
+			Buckets: []HistogramBucket{
+				{UpperBound: 10 * time.Millisecond, Count: 2},
+				{UpperBound: 100 * time.Millisecond, Count: 3},
+				{UpperBound: time.Second, Count: 3},
+			},
+			StatusCodes: map[int]int64{http.StatusOK: 2, http.StatusBadGateway: 1, 0: 1},
+			ErrNos:      map[int]int64{0: 3, 40002: 1},
+		},
+		"tp.template.get_tpl_list": {
+			Count: 1,
+			Sum:   time.Second,
+			Buckets: []HistogramBucket{
+				{UpperBound: 10 * time.Millisecond},
+				{UpperBound: 100 * time.Millisecond},
+				{UpperBound: time.Second, Count: 1},
+			},
+			StatusCodes: map[int]int64{http.StatusOK: 1},
+			ErrNos:      map[int]int64{0: 1},
+		},
+	}
+	if got := m.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot returned %+v, want %+v", got, want)
+	}
+
+	m.Reset()
+	if got := m.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot returned %+v after Reset, want none", got)
+	}
+}
+
+func TestMemoryMetrics_Snapshot_isolated(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.ObserveRequest("microapp.app.info", time.Millisecond, http.StatusOK, 0)
+
+	snapshot := m.Snapshot()
+	e := snapshot["microapp.app.info"]
+	e.Buckets[0].Count = 100
+	e.StatusCodes[http.StatusOK] = 100
+	e.ErrNos[40002] = 100
+
+	m.ObserveRequest("microapp.app.info", time.Millisecond, http.StatusOK, 0)
+	got := m.Snapshot()["microapp.app.info"]
+	if got.Buckets[0].Count != 2 || got.StatusCodes[http.StatusOK] != 2 || got.ErrNos[40002] != 0 {
+		t.Errorf("Snapshot returned %+v after a snapshot is changed", got)
+	}
+	if e.Count != 1 || e.StatusCodes[http.StatusOK] != 100 {
+		t.Errorf("snapshot is changed to %+v by later calls", e)
+	}
+	if len(got.Buckets) != len(DefaultLatencyBuckets) {
+		t.Errorf("Snapshot returned %d buckets, want %d", len(got.Buckets), len(DefaultLatencyBuckets))
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := NewMemoryMetrics()
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errno":40001,"message":"invalid parameter"}`))
+	}), WithMetrics(m))
+
+	req, _ := c.NewRequest(http.MethodGet, "v1/microapp/app/info", nil)
+	if _, err := c.Do(context.Background(), req, nil); err == nil {
+		t.Fatal("Do returned no error")
+	}
+	got, ok := m.Snapshot()["microapp.app.info"]
+	if !ok || got.Count != 1 || got.StatusCodes[http.StatusOK] != 1 || got.ErrNos[40001] != 1 {
+		t.Errorf("Snapshot returned %+v, want a call of errno 40001", m.Snapshot())
+	}
+}
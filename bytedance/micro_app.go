@@ -2,8 +2,9 @@ package bytedance
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 )
 
 // MicroAppService handles communication with the micro app related
@@ -71,9 +72,11 @@ type AppInfo struct {
 
 // GetAppInfo 获取应用信息
 func (s *MicroAppService) GetAppInfo(ctx context.Context, componentAppID, authorizerAccessToken string) (
-	*AppInfo, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/info?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	*AppInfo, *Response, error) {
+	u := withQuery("v1/microapp/app/info", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -96,9 +99,11 @@ type DownloadQrcodeRequest struct {
 
 // DownloadQrcode 获取二维码
 func (s *MicroAppService) DownloadQrcode(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *DownloadQrcodeRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/qrcode?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *DownloadQrcodeRequest) (*Response, error) {
+	u := withQuery("v1/microapp/app/qrcode", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -107,10 +112,39 @@ func (s *MicroAppService) DownloadQrcode(ctx context.Context, componentAppID, au
 	return s.client.Do(ctx, req, nil)
 }
 
+// DownloadQrcodeTo 获取二维码，写入 w
+// The image is streamed to w without being buffered, an errno response is
+// returned as *ErrorResponse.
+func (s *MicroAppService) DownloadQrcodeTo(ctx context.Context, componentAppID, authorizerAccessToken string,
+	body *DownloadQrcodeRequest, w io.Writer) (*Response, error) {
+	u := withQuery("v1/microapp/app/qrcode", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
+
+	req, err := s.client.NewRequest(http.MethodPost, u, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, w)
+}
+
+// DownloadQrcodeToFile 获取二维码，保存到 path
+// The file is replaced only if the whole image is downloaded.
+func (s *MicroAppService) DownloadQrcodeToFile(ctx context.Context, componentAppID, authorizerAccessToken string,
+	body *DownloadQrcodeRequest, path string) (*Response, error) {
+	return downloadToFile(path, func(w io.Writer) (*Response, error) {
+		return s.DownloadQrcodeTo(ctx, componentAppID, authorizerAccessToken, body, w)
+	})
+}
+
 // CheckAppName 小程序名称检测
-func (s *MicroAppService) CheckAppName(ctx context.Context, componentAppID, authorizerAccessToken, appName string) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/check_app_name?component_appid=%v&authorizer_access_token=%v&app_name=%v",
-		componentAppID, authorizerAccessToken, appName)
+func (s *MicroAppService) CheckAppName(ctx context.Context, componentAppID, authorizerAccessToken, appName string) (*Response, error) {
+	u := withQuery("v1/microapp/app/check_app_name", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+		"app_name":                {appName},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -127,9 +161,11 @@ type ModifyAppNameRequest struct {
 
 // ModifyAppName 修改小程序名称
 func (s *MicroAppService) ModifyAppName(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *ModifyAppNameRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/modify_app_name?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *ModifyAppNameRequest) (*Response, error) {
+	u := withQuery("v1/microapp/app/modify_app_name", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -145,9 +181,11 @@ type ModifyAppIntroRequest struct {
 
 // ModifyIntro 修改小程序简介
 func (s *MicroAppService) ModifyIntro(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *ModifyAppIntroRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/modify_app_intro?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *ModifyAppIntroRequest) (*Response, error) {
+	u := withQuery("v1/microapp/app/modify_app_intro", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -163,9 +201,11 @@ type ModifyAppIconRequest struct {
 
 // ModifyAppIcon 修改小程序图标
 func (s *MicroAppService) ModifyAppIcon(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *ModifyAppIconRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/modify_app_intro?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *ModifyAppIconRequest) (*Response, error) {
+	u := withQuery("v1/microapp/app/modify_app_icon", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -194,9 +234,11 @@ type ServerDomain struct {
 
 // ModifyServerDomain 修改服务域名
 func (s *MicroAppService) ModifyServerDomain(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *ModifyServerDomainRequest) (*ServerDomain, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/modify_server_domain?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *ModifyServerDomainRequest) (*ServerDomain, *Response, error) {
+	u := withQuery("v1/microapp/app/modify_server_domain", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -219,24 +261,27 @@ type ModifyWebviewDomainRequest struct {
 
 // ModifyWebviewDomain 修改webview域名
 func (s *MicroAppService) ModifyWebviewDomain(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *ModifyWebviewDomainRequest) ([]string, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/app/modify_webview_domain?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *ModifyWebviewDomainRequest) ([]string, *Response, error) {
+	u := withQuery("v1/microapp/app/modify_webview_domain", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
 		return nil, nil, err
 	}
+	// only get returns the domains.
 	var webview []string
+	var v interface{}
 	if body.Action == "get" {
-		resp, err := s.client.Do(ctx, req, &webview)
-		if err != nil {
-			return nil, resp, err
-		}
+		v = &webview
 	}
-	resp, err := s.client.Do(ctx, req, nil)
-
-	return webview, resp, err
+	resp, err := s.client.Do(ctx, req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return webview, resp, nil
 }
 
 // Session 返回
@@ -248,9 +293,13 @@ type Session struct {
 
 // Code2Session code2session
 func (s *MicroAppService) Code2Session(ctx context.Context, componentAppID, authorizerAccessToken,
-	code, anonymousCode string) (*Session, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/code2session?component_appid=%v&authorizer_access_token=%v&code=%v&anonymous_code=%v",
-		componentAppID, authorizerAccessToken, code, anonymousCode)
+	code, anonymousCode string) (*Session, *Response, error) {
+	u := withQuery("v1/microapp/code2session", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+		"code":                    {code},
+		"anonymous_code":          {anonymousCode},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -3,8 +3,8 @@ package bytedance
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // UploadPackageRequest 上传代码请求.
@@ -18,9 +18,11 @@ type UploadPackageRequest struct {
 // UploadPackage 提交代码
 // 为授权小程序提交代码（提交成功后，授权小程序具有测试版本）.
 func (s *MicroAppService) UploadPackage(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *UploadPackageRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/package/upload?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *UploadPackageRequest) (*Response, error) {
+	u := withQuery("v1/microapp/package/upload", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -38,9 +40,11 @@ type PackageAuditHosts struct {
 // GetPackageAuditHosts 获取可选审核宿主端列表
 // 获取可以提审的端，作为参数传入提审代码v2接口中
 func (s *MicroAppService) GetPackageAuditHosts(ctx context.Context, componentAppID, authorizerAccessToken string) (
-	*PackageAuditHosts, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/package/audit_hosts?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	*PackageAuditHosts, *Response, error) {
+	u := withQuery("v1/microapp/package/audit_hosts", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -63,9 +67,11 @@ type CommitAuditPackageRequest struct {
 // CommitAuditPackage 提审代码 v2 支持传入宿主端参数
 // 为授权小程序提审代码（审核成功后，授权小程序具有审核版本）
 func (s *MicroAppService) CommitAuditPackage(ctx context.Context, componentAppID, authorizerAccessToken string,
-	body *CommitAuditPackageRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v2/microapp/package/audit?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	body *CommitAuditPackageRequest) (*Response, error) {
+	u := withQuery("v2/microapp/package/audit", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -77,9 +83,11 @@ func (s *MicroAppService) CommitAuditPackage(ctx context.Context, componentAppID
 // ReleasePackage 发布代码
 // 为授权小程序发布代码（发布成功后，授权小程序具有线上版本）
 func (s *MicroAppService) ReleasePackage(ctx context.Context, componentAppID, authorizerAccessToken string) (
-	*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/package/release?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	*Response, error) {
+	u := withQuery("v1/microapp/package/release", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, nil)
 	if err != nil {
@@ -91,9 +99,11 @@ func (s *MicroAppService) ReleasePackage(ctx context.Context, componentAppID, au
 // RollbackPackage 回退代码版本
 // 为授权小程序回退代码版本，此操作可能需要等待一会（如果可以回退，执行成功后，授权小程序将回退至上一个线上版本）
 func (s *MicroAppService) RollbackPackage(ctx context.Context, componentAppID, authorizerAccessToken string) (
-	*http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/package/rollback?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	*Response, error) {
+	u := withQuery("v1/microapp/package/rollback", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, nil)
 	if err != nil {
@@ -165,9 +175,11 @@ type PackageVersions struct {
 // GetPackageVersions 获取小程序版本列表信息
 // 返回的结果列表中一共会展示三种状态的小程序代码版本信息，包括测试版本、审核版本、线上版本。
 func (s *MicroAppService) GetPackageVersions(ctx context.Context, componentAppID, authorizerAccessToken string) (
-	*PackageVersions, *http.Response, error) {
-	u := fmt.Sprintf("v1/microapp/package/versions?component_appid=%v&authorizer_access_token=%v",
-		componentAppID, authorizerAccessToken)
+	*PackageVersions, *Response, error) {
+	u := withQuery("v1/microapp/package/versions", url.Values{
+		"component_appid":         {componentAppID},
+		"authorizer_access_token": {authorizerAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
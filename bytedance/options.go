@@ -0,0 +1,95 @@
+package bytedance
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created by NewClient.
+type Option func(*Client)
+
+// Logger logs the calls of a Client, *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithHTTPClient sets the HTTP client used to send requests, e.g. to use a
+// custom transport, proxy or TLS config.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.client = hc
+		}
+	}
+}
+
+// WithBaseURL sets the base URL of API requests, a trailing slash is added if missing.
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(c *Client) {
+		u := *baseURL
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		c.BaseURL = &u
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. It's applied after all
+// options to a copy of the HTTP client, so a client given by WithHTTPClient is
+// not modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy of the client.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *Client) {
+		c.RetryPolicy = policy
+	}
+}
+
+// WithLogger sets the Logger of the client.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithTokenStore sets the TokenStore of the client.
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Client) {
+		c.TokenStore = store
+	}
+}
+
+// WithReauthenticate sets the ReauthFunc of the client.
+func WithReauthenticate(fn ReauthFunc) Option {
+	return func(c *Client) {
+		c.Reauthenticate = fn
+	}
+}
+
+// WithRateLimiter sets the RateLimiter of the client.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.RateLimiter = limiter
+	}
+}
+
+// WithoutValidation disables validating request bodies before they are sent.
+func WithoutValidation() Option {
+	return func(c *Client) {
+		c.SkipValidation = true
+	}
+}
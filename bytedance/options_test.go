@@ -0,0 +1,33 @@
+package bytedance
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout_optionOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(hc *http.Client) []Option
+	}{
+		{"after WithHTTPClient", func(hc *http.Client) []Option {
+			return []Option{WithHTTPClient(hc), WithTimeout(time.Second)}
+		}},
+		{"before WithHTTPClient", func(hc *http.Client) []Option {
+			return []Option{WithTimeout(time.Second), WithHTTPClient(hc)}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &http.Client{}
+			c := NewClient(tt.opts(hc)...)
+			if got := c.client.Timeout; got != time.Second {
+				t.Errorf("client timeout is %v, want %v", got, time.Second)
+			}
+			if hc.Timeout != 0 {
+				t.Errorf("WithTimeout modified the given HTTP client")
+			}
+		})
+	}
+}
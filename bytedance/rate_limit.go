@@ -0,0 +1,206 @@
+package bytedance
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is the limit of a token bucket.
+type RateLimit struct {
+	// QPS is the sustained number of calls per second, zero means unlimited.
+	QPS float64
+
+	// Burst is the number of calls allowed at once, at least 1.
+	Burst int
+}
+
+type authorizerAppIDKey struct{}
+
+// WithAuthorizerAppID returns a copy of ctx carrying the authorizer appid of the
+// call, which is used to apply per authorizer rate limits.
+func WithAuthorizerAppID(ctx context.Context, authorizerAppID string) context.Context {
+	return context.WithValue(ctx, authorizerAppIDKey{}, authorizerAppID)
+}
+
+// AuthorizerAppIDFromContext returns the authorizer appid set by WithAuthorizerAppID.
+func AuthorizerAppIDFromContext(ctx context.Context) (string, bool) {
+	appID, ok := ctx.Value(authorizerAppIDKey{}).(string)
+	return appID, ok && appID != ""
+}
+
+// RateLimiterStats is the accumulated waiting of a RateLimiter.
+type RateLimiterStats struct {
+	Waits    int64
+	WaitTime time.Duration
+}
+
+// RateLimiter is a client side token bucket rate limiter keyed by endpoint path,
+// e.g. "v1/microapp/app/info", and by authorizer appid, which is taken from
+// the context of the call, see WithAuthorizerAppID. A call waits for both of
+// its buckets.
+type RateLimiter struct {
+	// OnWait, when not nil, is called after a call waited d for its buckets.
+	OnWait func(endpoint, authorizerAppID string, d time.Duration)
+
+	mu               sync.Mutex
+	endpointLimits   map[string]RateLimit
+	authorizerLimits map[string]RateLimit
+	buckets          map[string]*tokenBucket
+	stats            RateLimiterStats
+}
+
+// NewRateLimiter returns a RateLimiter without any limit.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		endpointLimits:   make(map[string]RateLimit),
+		authorizerLimits: make(map[string]RateLimit),
+		buckets:          make(map[string]*tokenBucket),
+	}
+}
+
+// SetEndpointLimit limits calls of the endpoint path. An empty path sets the
+// default limit of every endpoint, each endpoint still has its own bucket.
+func (l *RateLimiter) SetEndpointLimit(path string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.endpointLimits[path] = limit
+	l.resetBuckets("endpoint:", path)
+}
+
+// SetAuthorizerLimit limits calls of the authorizer. An empty appid sets the
+// default limit of every authorizer, each authorizer still has its own bucket.
+func (l *RateLimiter) SetAuthorizerLimit(authorizerAppID string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.authorizerLimits[authorizerAppID] = limit
+	l.resetBuckets("authorizer:", authorizerAppID)
+}
+
+// resetBuckets drops the bucket of key, or every bucket of prefix if key is
+// empty, so that they are recreated with the new limit. l.mu must be held.
+func (l *RateLimiter) resetBuckets(prefix, key string) {
+	if key != "" {
+		delete(l.buckets, prefix+key)
+		return
+	}
+	for k := range l.buckets {
+		if strings.HasPrefix(k, prefix) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// Stats returns the accumulated waiting of l.
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Wait blocks until a call of endpoint by authorizerAppID is allowed, or ctx
+// is done. It returns how long it waited.
+func (l *RateLimiter) Wait(ctx context.Context, endpoint, authorizerAppID string) (time.Duration, error) {
+	now := time.Now()
+	l.mu.Lock()
+	var reserved []*tokenBucket
+	var delay time.Duration
+	if b := l.bucket("endpoint:", endpoint, l.endpointLimits); b != nil {
+		delay = b.reserve(now)
+		reserved = append(reserved, b)
+	}
+	if authorizerAppID != "" {
+		if b := l.bucket("authorizer:", authorizerAppID, l.authorizerLimits); b != nil {
+			if d := b.reserve(now); d > delay {
+				delay = d
+			}
+			reserved = append(reserved, b)
+		}
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, b := range reserved {
+			b.cancel()
+		}
+		l.mu.Unlock()
+		return time.Since(now), ctx.Err()
+	}
+
+	l.mu.Lock()
+	l.stats.Waits++
+	l.stats.WaitTime += delay
+	l.mu.Unlock()
+	if l.OnWait != nil {
+		l.OnWait(endpoint, authorizerAppID, delay)
+	}
+	return delay, nil
+}
+
+// bucket returns the bucket of key, or nil if it's not limited. l.mu must be held.
+func (l *RateLimiter) bucket(prefix, key string, limits map[string]RateLimit) *tokenBucket {
+	limit, ok := limits[key]
+	if !ok {
+		if limit, ok = limits[""]; !ok {
+			return nil
+		}
+	}
+	b, ok := l.buckets[prefix+key]
+	if !ok {
+		b = newTokenBucket(limit)
+		l.buckets[prefix+key] = b
+	}
+	return b
+}
+
+// tokenBucket is a token bucket, it's guarded by the mutex of its RateLimiter.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.QPS, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve takes a token and returns how long to wait until it's available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back a token taken by reserve.
+func (b *tokenBucket) cancel() {
+	if b.rate > 0 {
+		b.tokens++
+	}
+}
@@ -0,0 +1,126 @@
+package bytedance
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	b := newTokenBucket(RateLimit{QPS: 10, Burst: 2})
+	now := b.last
+	steps := []struct {
+		after time.Duration
+		want  time.Duration
+	}{
+		{0, 0},                      // burst
+		{0, 0},                      // burst
+		{0, 100 * time.Millisecond}, // one token short
+		{0, 200 * time.Millisecond}, // two tokens short
+		{time.Second, 0},            // refilled to burst
+		{0, 0},                      // burst
+		{50 * time.Millisecond, 50 * time.Millisecond},
+	}
+	for i, s := range steps {
+		now = now.Add(s.after)
+		if got := b.reserve(now); got != s.want {
+			t.Errorf("step %d: reserve returned %v, want %v", i, got, s.want)
+		}
+	}
+}
+
+func TestTokenBucket_unlimited(t *testing.T) {
+	b := newTokenBucket(RateLimit{})
+	for i := 0; i < 100; i++ {
+		if d := b.reserve(b.last); d != 0 {
+			t.Fatalf("reserve returned %v, want 0", d)
+		}
+	}
+	if b.burst != 1 {
+		t.Errorf("burst is %v, want at least 1", b.burst)
+	}
+}
+
+func TestTokenBucket_cancel(t *testing.T) {
+	b := newTokenBucket(RateLimit{QPS: 10, Burst: 1})
+	b.reserve(b.last)
+	if d := b.reserve(b.last); d != 100*time.Millisecond {
+		t.Fatalf("reserve returned %v, want 100ms", d)
+	}
+	b.cancel()
+	if d := b.reserve(b.last); d != 100*time.Millisecond {
+		t.Errorf("reserve after cancel returned %v, want 100ms", d)
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := NewRateLimiter()
+	var waited []string
+	l.OnWait = func(endpoint, authorizerAppID string, d time.Duration) {
+		waited = append(waited, endpoint+"|"+authorizerAppID)
+	}
+	l.SetEndpointLimit("", RateLimit{QPS: 1, Burst: 1})
+	l.SetEndpointLimit("v1/fast", RateLimit{QPS: 1000, Burst: 1})
+	l.SetAuthorizerLimit("app", RateLimit{QPS: 50, Burst: 1})
+	ctx := context.Background()
+
+	// the first call of every bucket is allowed at once.
+	for _, call := range [][2]string{{"v1/a", ""}, {"v1/b", ""}, {"v1/fast", "app"}} {
+		if d, err := l.Wait(ctx, call[0], call[1]); d != 0 || err != nil {
+			t.Errorf("Wait(%v, %v) returned %v, %v, want no wait", call[0], call[1], d, err)
+		}
+	}
+
+	// the authorizer bucket is slower than the endpoint one.
+	d, err := l.Wait(ctx, "v1/fast", "app")
+	if err != nil || d < 10*time.Millisecond || d > 20*time.Millisecond {
+		t.Errorf("Wait returned %v, %v, want about 20ms", d, err)
+	}
+	// other authorizers are not limited.
+	if d, _ := l.Wait(ctx, "v1/fast", "other"); d > time.Millisecond {
+		t.Errorf("Wait of other authorizer returned %v, want at most 1ms", d)
+	}
+
+	stats := l.Stats()
+	if stats.Waits != int64(len(waited)) || stats.Waits < 1 || stats.WaitTime < d {
+		t.Errorf("Stats returned %+v, want the %d waits reported to OnWait", stats, len(waited))
+	}
+}
+
+func TestRateLimiter_Wait_canceled(t *testing.T) {
+	l := NewRateLimiter()
+	l.SetEndpointLimit("", RateLimit{QPS: 1, Burst: 1})
+	if _, err := l.Wait(context.Background(), "v1/a", ""); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx, "v1/a", ""); err != context.DeadlineExceeded {
+		t.Errorf("Wait returned error %v, want context.DeadlineExceeded", err)
+	}
+	if stats := l.Stats(); stats.Waits != 0 {
+		t.Errorf("Stats returned %+v, want no completed waits", stats)
+	}
+
+	// the canceled call gave its token back.
+	b := l.buckets["endpoint:v1/a"]
+	if b.tokens < -0.1 {
+		t.Errorf("bucket has %v tokens, want the canceled one given back", b.tokens)
+	}
+}
+
+func TestRateLimiter_SetEndpointLimit_resets(t *testing.T) {
+	l := NewRateLimiter()
+	l.SetEndpointLimit("", RateLimit{QPS: 1, Burst: 1})
+	ctx := context.Background()
+	_, _ = l.Wait(ctx, "v1/a", "")
+
+	// raising the limit applies to the existing bucket.
+	l.SetEndpointLimit("", RateLimit{QPS: 1, Burst: 5})
+	for i := 0; i < 5; i++ {
+		if d, _ := l.Wait(ctx, "v1/a", ""); d != 0 {
+			t.Fatalf("Wait %d returned %v, want no wait", i, d)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Query parameters carrying access tokens.
+const (
+	ParamComponentAccessToken  = "component_access_token"
+	ParamAuthorizerAccessToken = "authorizer_access_token"
+)
+
+// ReauthFunc returns a new token to replace stale, the value of query parameter
+// param which was rejected by the server as invalid or expired. param is either
+// ParamComponentAccessToken or ParamAuthorizerAccessToken.
+type ReauthFunc func(ctx context.Context, param, stale string) (string, error)
+
+// NewReauthFunc returns a ReauthFunc renewing component_access_token by ts and
+// authorizer_access_token by m, either may be nil. The authorizer of a stale
+// token unknown to m is taken from the context, see WithAuthorizerAppID.
+func NewReauthFunc(ts *ComponentTokenSource, m *AuthorizerTokenManager) ReauthFunc {
+	return func(ctx context.Context, param, stale string) (string, error) {
+		switch {
+		case param == ParamComponentAccessToken && ts != nil:
+			return ts.Renew(ctx, stale)
+		case param == ParamAuthorizerAccessToken && m != nil:
+			appID, ok := m.AuthorizerAppID(stale)
+			if !ok {
+				// stale may be rotated already, or issued to another process.
+				appID, ok = AuthorizerAppIDFromContext(ctx)
+			}
+			if !ok {
+				return "", errors.New("unknown authorizer_access_token")
+			}
+			return m.Renew(ctx, appID, stale)
+		}
+		return "", errors.New("no token source for " + param)
+	}
+}
+
+// ReauthError is returned if a request rejected for its token can not be
+// re-authenticated. It unwraps to the error of the request, e.g. an
+// *ErrorResponse matching ErrInvalidToken.
+type ReauthError struct {
+	Err       error // error of the request
+	ReauthErr error // error of Reauthenticate
+}
+
+func (e *ReauthError) Error() string {
+	return fmt.Sprintf("%v (reauthenticate: %v)", e.Err, e.ReauthErr)
+}
+
+// Unwrap returns the error of the request.
+func (e *ReauthError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error of Reauthenticate matches target.
+func (e *ReauthError) Is(target error) bool {
+	return errors.Is(e.ReauthErr, target)
+}
+
+// reauthRequest returns a copy of req with the token rejected by err replaced by a
+// new one from c.Reauthenticate, or nil if req can not be re-authenticated.
+func (c *Client) reauthRequest(ctx context.Context, req *http.Request, err error) (*http.Request, error) {
+	if c.Reauthenticate == nil || !IsTokenError(err) {
+		return nil, nil
+	}
+
+	q := req.URL.Query()
+	param := ParamAuthorizerAccessToken
+	stale := q.Get(param)
+	if stale == "" {
+		param = ParamComponentAccessToken
+		stale = q.Get(param)
+	}
+	if stale == "" {
+		return nil, nil
+	}
+
+	token, err := c.Reauthenticate(ctx, param, stale)
+	if err != nil {
+		return nil, err
+	}
+	q.Set(param, token)
+
+	u := *req.URL
+	u.RawQuery = q.Encode()
+	req = req.Clone(ctx)
+	req.URL = &u
+	return req, nil
+}
@@ -0,0 +1,66 @@
+package bytedance_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+// setupAuthorizer returns a client of s sharing store, and the access token of
+// a new authorizer issued through another manager, as by another replica.
+func setupAuthorizer(t *testing.T, s *bytedancetest.Server, store bytedance.TokenStore) (
+	c *bytedance.Client, ts *bytedance.ComponentTokenSource, appID, accessToken string) {
+	t.Helper()
+	c = s.Client(bytedance.WithTokenStore(store))
+	ts = bytedance.NewComponentTokenSource(c, bytedancetest.ComponentAppID, bytedancetest.ComponentAppSecret,
+		bytedance.StaticTicket(s.Ticket()))
+	appID, code := s.AddAuthorizer("app")
+	replica := bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts)
+	token, err := replica.Authorize(context.Background(), code)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	return c, ts, appID, token.AuthorizerAccessToken
+}
+
+func TestReauthenticate_authorizerFromContext(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, ts, appID, stale := setupAuthorizer(t, s, bytedance.NewMemoryTokenStore())
+	c.Reauthenticate = bytedance.NewReauthFunc(ts, bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts))
+	s.RevokeTokens()
+
+	ctx := bytedance.WithAuthorizerAppID(context.Background(), appID)
+	info, _, err := c.MicroApp.GetAppInfo(ctx, bytedancetest.ComponentAppID, stale)
+	if err != nil {
+		t.Fatalf("GetAppInfo returned error: %v", err)
+	}
+	if info.AppID != appID {
+		t.Errorf("GetAppInfo returned appid %v, want %v", info.AppID, appID)
+	}
+}
+
+func TestReauthenticate_failureKeepsRequestError(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, ts, _, stale := setupAuthorizer(t, s, bytedance.NewMemoryTokenStore())
+	c.Reauthenticate = bytedance.NewReauthFunc(ts, bytedance.NewAuthorizerTokenManager(c, bytedancetest.ComponentAppID, ts))
+	s.RevokeTokens()
+
+	// the authorizer of stale is unknown without it in the context.
+	_, _, err := c.MicroApp.GetAppInfo(context.Background(), bytedancetest.ComponentAppID, stale)
+	if !errors.Is(err, bytedance.ErrInvalidToken) {
+		t.Errorf("GetAppInfo returned error %v, want ErrInvalidToken", err)
+	}
+	var errResp *bytedance.ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Errorf("GetAppInfo returned error %T, want it to wrap *ErrorResponse", err)
+	}
+	var reauthErr *bytedance.ReauthError
+	if !errors.As(err, &reauthErr) || reauthErr.ReauthErr == nil {
+		t.Errorf("GetAppInfo returned error %v, want *ReauthError with the reauth error", err)
+	}
+}
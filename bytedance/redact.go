@@ -0,0 +1,194 @@
+package bytedance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values of sensitive keys.
+const redacted = "REDACTED"
+
+var (
+	sensitiveMu sync.RWMutex
+
+	// sensitiveKeys are the query parameters and JSON fields to redact.
+	sensitiveKeys = map[string]bool{
+		"component_appsecret":      true,
+		"component_access_token":   true,
+		"component_ticket":         true,
+		"authorizer_access_token":  true,
+		"authorizer_refresh_token": true,
+		"authorization_code":       true,
+		"pre_auth_code":            true,
+		"session_key":              true,
+		"code":                     true,
+		"anonymous_code":           true,
+		"ticket":                   true,
+		"encrypt":                  true,
+	}
+)
+
+// RegisterSensitiveKeys adds query parameters and JSON fields to redact in
+// errors, logs and dumps. Keys are case insensitive.
+func RegisterSensitiveKeys(keys ...string) {
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	for _, key := range keys {
+		sensitiveKeys[strings.ToLower(key)] = true
+	}
+}
+
+// IsSensitiveKey reports whether the value of key is redacted.
+func IsSensitiveKey(key string) bool {
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactURL returns u as a string with the values of sensitive query parameters masked.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redactedURL := *u
+	redactedURL.RawQuery = RedactQuery(u.RawQuery)
+	return redactedURL.String()
+}
+
+// RedactQuery returns the encoded query with the values of sensitive parameters
+// masked, the order of parameters is kept.
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		key, err := url.QueryUnescape(kv[0])
+		if err != nil {
+			key = kv[0]
+		}
+		if len(kv) == 2 && IsSensitiveKey(key) {
+			pairs[i] = kv[0] + "=" + redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// RedactJSON returns data with the values of sensitive fields masked at any
+// depth. data is returned unchanged if it's not JSON.
+func RedactJSON(data []byte) []byte {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if len(data) == 0 || dec.Decode(&v) != nil {
+		return data
+	}
+	if !redactValue(v) {
+		return data
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return data
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactValue masks sensitive fields of v in place, and reports whether any was masked.
+func redactValue(v interface{}) bool {
+	var changed bool
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			if IsSensitiveKey(key) {
+				if _, isObject := value.(map[string]interface{}); !isObject {
+					x[key] = redacted
+					changed = true
+					continue
+				}
+			}
+			if redactValue(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range x {
+			if redactValue(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// DumpRequest is like httputil.DumpRequestOut, but sensitive query parameters
+// and JSON fields are masked.
+func DumpRequest(req *http.Request, body bool) ([]byte, error) {
+	r := req.Clone(req.Context())
+	u := *req.URL
+	u.RawQuery = RedactQuery(u.RawQuery)
+	r.URL = &u
+	if body && req.Body != nil && req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = RedactJSON(data)
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		r.ContentLength = int64(len(data))
+	} else {
+		body = false
+	}
+	return httputil.DumpRequestOut(r, body)
+}
+
+// DumpResponse is like httputil.DumpResponse, but sensitive JSON fields are
+// masked. The body of resp is restored after it's read.
+func DumpResponse(resp *http.Response, body bool) ([]byte, error) {
+	r := *resp
+	if body && resp.Body != nil {
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data = RedactJSON(data)
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		r.ContentLength = int64(len(data))
+	}
+	return httputil.DumpResponse(&r, body)
+}
+
+// DebugInterceptor returns an Interceptor logging redacted dumps of every
+// request and response to logger. Bodies are dumped only if they are JSON, so
+// that uploads and streamed downloads are neither logged nor buffered.
+func DebugInterceptor(logger Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		if dump, err := DumpRequest(call.Request, isJSON(call.Request.Header)); err == nil {
+			logger.Printf("bytedance: %v request:\n%s", call.Endpoint, dump)
+		}
+		err := next(ctx, call)
+		if call.Response != nil && call.Response.Response != nil {
+			resp := call.Response.Response
+			if dump, dumpErr := DumpResponse(resp, isJSON(resp.Header)); dumpErr == nil {
+				logger.Printf("bytedance: %v response:\n%s", call.Endpoint, dump)
+			}
+		}
+		return err
+	}
+}
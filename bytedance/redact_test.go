@@ -0,0 +1,189 @@
+package bytedance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDo_transportErrorRedacted(t *testing.T) {
+	// a closed port refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	logger := new(bufferLogger)
+	baseURL, _ := url.Parse("http://" + addr + "/")
+	c := NewClient(WithBaseURL(baseURL), WithLogger(logger))
+	_, _, err = c.ThirdParty.GetComponentAccessToken(context.Background(), "appid", "SUPERSECRET", "TICKET")
+	if err == nil {
+		t.Fatal("GetComponentAccessToken returned no error")
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("GetComponentAccessToken returned error %T, want *url.Error", err)
+	}
+	for _, secret := range []string{"SUPERSECRET", "TICKET"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("error %q contains %v", err, secret)
+		}
+		if strings.Contains(logger.String(), secret) {
+			t.Errorf("log %q contains %v", logger.String(), secret)
+		}
+	}
+}
+
+func TestErrorResponse_Error_redacted(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(HeaderLogID, "log-id")
+		_, _ = w.Write([]byte(`{"errno":40001,"message":"invalid parameter"}`))
+	}))
+	body := map[string]interface{}{
+		"code": "CODE",
+		"user": map[string]interface{}{"session_key": "SESSIONKEY", "name": "visible"},
+	}
+	req, err := c.NewRequest(http.MethodPost, "v1/test?component_appid=appid&component_appsecret=SUPERSECRET", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Do(context.Background(), req, nil)
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Fatalf("Do returned error %v, want *ErrorResponse", err)
+	}
+	msg := err.Error()
+	for _, secret := range []string{"SUPERSECRET", "CODE", "SESSIONKEY"} {
+		if strings.Contains(msg, secret) {
+			t.Errorf("error %q contains %v", msg, secret)
+		}
+	}
+	for _, want := range []string{"component_appid=appid", "component_appsecret=REDACTED", `"name":"visible"`,
+		"40001 invalid parameter", "(log id log-id)"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %v", msg, want)
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", ``, ``},
+		{"not JSON", `code=123`, `code=123`},
+		{"nothing sensitive", `{"a": 1}`, `{"a": 1}`},
+		{"top level", `{"component_access_token":"T","a":1}`, `{"a":1,"component_access_token":"REDACTED"}`},
+		{"nested", `{"data":{"authorizer_refresh_token":"R","list":[{"ticket":"X"},{"b":"keep"}]}}`,
+			`{"data":{"authorizer_refresh_token":"REDACTED","list":[{"ticket":"REDACTED"},{"b":"keep"}]}}`},
+		{"sensitive object is searched", `{"code":{"session_key":"S","n":1}}`,
+			`{"code":{"n":1,"session_key":"REDACTED"}}`},
+		{"case insensitive", `{"Session_Key":"S"}`, `{"Session_Key":"REDACTED"}`},
+		{"numbers kept", `{"code":"C","big":12345678901234567890}`, `{"big":12345678901234567890,"code":"REDACTED"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactJSON([]byte(tt.data))); got != tt.want {
+				t.Errorf("RedactJSON returned %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	got := RedactQuery("b=1&component_ticket=a%2Bb&authorizer_access_token=T&a=2&flag")
+	if want := "b=1&component_ticket=REDACTED&authorizer_access_token=REDACTED&a=2&flag"; got != want {
+		t.Errorf("RedactQuery returned %v, want %v", got, want)
+	}
+}
+
+func TestRegisterSensitiveKeys(t *testing.T) {
+	if IsSensitiveKey("x_custom_secret") {
+		t.Fatal("x_custom_secret is sensitive before it's registered")
+	}
+	RegisterSensitiveKeys("X_Custom_Secret")
+	t.Cleanup(func() {
+		sensitiveMu.Lock()
+		delete(sensitiveKeys, "x_custom_secret")
+		sensitiveMu.Unlock()
+	})
+
+	if !IsSensitiveKey("x_custom_secret") {
+		t.Error("IsSensitiveKey returned false for a registered key")
+	}
+	if got := RedactQuery("x_custom_secret=S"); got != "x_custom_secret=REDACTED" {
+		t.Errorf("RedactQuery returned %v", got)
+	}
+	if got := string(RedactJSON([]byte(`{"X_CUSTOM_SECRET":"S"}`))); got != `{"X_CUSTOM_SECRET":"REDACTED"}` {
+		t.Errorf("RedactJSON returned %v", got)
+	}
+}
+
+func TestDumpRequest(t *testing.T) {
+	c := NewClient()
+	req, err := c.NewRequest(http.MethodPost, "v1/test?component_access_token=TOKEN&component_appid=appid",
+		map[string]string{"authorization_code": "CODE", "name": "visible"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := DumpRequest(req, true)
+	if err != nil {
+		t.Fatalf("DumpRequest returned error: %v", err)
+	}
+	for _, secret := range []string{"TOKEN", "CODE"} {
+		if bytes.Contains(dump, []byte(secret)) {
+			t.Errorf("dump %s contains %v", dump, secret)
+		}
+	}
+	for _, want := range []string{"component_appid=appid", `"name":"visible"`} {
+		if !bytes.Contains(dump, []byte(want)) {
+			t.Errorf("dump %s does not contain %v", dump, want)
+		}
+	}
+
+	// the request is sent as it is.
+	body, _ := ioutil.ReadAll(req.Body)
+	if !bytes.Contains(body, []byte("CODE")) || req.URL.Query().Get(ParamComponentAccessToken) != "TOKEN" {
+		t.Errorf("DumpRequest changed the request to %v with body %s", req.URL, body)
+	}
+}
+
+func TestDebugInterceptor(t *testing.T) {
+	logger := new(bufferLogger)
+	c := setupDownload(t, WithInterceptors(DebugInterceptor(logger)))
+
+	var buf bytes.Buffer
+	if _, err := c.MicroApp.DownloadQrcodeTo(context.Background(), "appid", "TOKEN",
+		&DownloadQrcodeRequest{Version: "current"}, &buf); err != nil {
+		t.Fatalf("DownloadQrcodeTo returned error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), pngData) {
+		t.Errorf("DownloadQrcodeTo wrote %q, want %q", buf.Bytes(), pngData)
+	}
+	log := logger.String()
+	if strings.Contains(log, string(pngData)) {
+		t.Errorf("log %q contains the downloaded image", log)
+	}
+	if !strings.Contains(log, `"version":"current"`) || !strings.Contains(log, "image/png") || strings.Contains(log, "TOKEN") {
+		t.Errorf("log %q does not dump the redacted JSON request and the response header", log)
+	}
+
+	// JSON responses are dumped redacted.
+	logger.Reset()
+	_, _ = c.MicroApp.DownloadQrcodeTo(context.Background(), "appid", "invalid",
+		&DownloadQrcodeRequest{Version: "current"}, &buf)
+	if log := logger.String(); !strings.Contains(log, `"errno":40004`) || !strings.Contains(log, "access_token=REDACTED") {
+		t.Errorf("log %q does not dump the redacted JSON response", log)
+	}
+}
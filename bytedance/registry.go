@@ -0,0 +1,200 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+type componentAppIDKey struct{}
+
+// WithComponentAppID returns a copy of ctx carrying the component appid whose
+// credentials are used by the call, see Registry.
+func WithComponentAppID(ctx context.Context, componentAppID string) context.Context {
+	return context.WithValue(ctx, componentAppIDKey{}, componentAppID)
+}
+
+// ComponentAppIDFromContext returns the component appid set by WithComponentAppID.
+func ComponentAppIDFromContext(ctx context.Context) (string, bool) {
+	appID, ok := ctx.Value(componentAppIDKey{}).(string)
+	return appID, ok && appID != ""
+}
+
+// componentAppIDOf returns componentAppID, or the component appid set by
+// WithComponentAppID if it's empty, so that services can key the TokenStore
+// before Registry fills in the query of the call.
+func componentAppIDOf(ctx context.Context, componentAppID string) string {
+	if componentAppID == "" {
+		componentAppID, _ = ComponentAppIDFromContext(ctx)
+	}
+	return componentAppID
+}
+
+// Platform is a third party platform (component app) registered in a Registry.
+type Platform struct {
+	ComponentAppID     string
+	ComponentAppSecret string
+
+	// TPToken and EncodingAESKey verify and decrypt the pushes of the platform.
+	TPToken        string
+	EncodingAESKey string
+
+	// Tickets supplies component_ticket, if nil it's read from the TokenStore
+	// of the Client.
+	Tickets TicketSource
+
+	componentToken *ComponentTokenSource
+	authorizers    *AuthorizerTokenManager
+}
+
+// ComponentToken returns the TokenSource of component_access_token of p.
+func (p *Platform) ComponentToken() *ComponentTokenSource {
+	return p.componentToken
+}
+
+// Authorizers returns the AuthorizerTokenManager of the authorizers of p.
+func (p *Platform) Authorizers() *AuthorizerTokenManager {
+	return p.authorizers
+}
+
+// Context returns a copy of ctx making calls use the credentials of p.
+func (p *Platform) Context(ctx context.Context) context.Context {
+	return WithComponentAppID(ctx, p.ComponentAppID)
+}
+
+// Registry holds the credentials of several third party platforms keyed by
+// component appid, for ISVs operating more than one.
+//
+// Calls through the Client of a Registry resolve credentials automatically:
+// the platform is selected by the component appid in the context, see
+// WithComponentAppID and Platform.Context, or else by the component_appid
+// query parameter. Then empty component_appid, component_appsecret,
+// component_ticket and component_access_token parameters are filled in, and
+// so is authorizer_access_token if the context carries the authorizer appid,
+// see WithAuthorizerAppID.
+type Registry struct {
+	client *Client
+
+	mu        sync.RWMutex
+	platforms map[string]*Platform
+}
+
+// NewRegistry returns an empty Registry for the calls through c, and makes c
+// resolve credentials from it. Set c.Reauthenticate to r.Reauthenticate to
+// renew the tokens of the platforms when rejected.
+func NewRegistry(c *Client) *Registry {
+	r := &Registry{client: c, platforms: make(map[string]*Platform)}
+	c.Platforms = r
+	return r
+}
+
+// Register adds or replaces the platform p, and sets up its token management.
+func (r *Registry) Register(p *Platform) (*Platform, error) {
+	if p.ComponentAppID == "" {
+		return nil, errors.New("component appid is empty")
+	}
+	p.componentToken = NewComponentTokenSource(r.client, p.ComponentAppID, p.ComponentAppSecret, p.Tickets)
+	p.authorizers = NewAuthorizerTokenManager(r.client, p.ComponentAppID, p.componentToken)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.platforms[p.ComponentAppID] = p
+	return p, nil
+}
+
+// Platform returns the platform of componentAppID.
+func (r *Registry) Platform(componentAppID string) (*Platform, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.platforms[componentAppID]
+	return p, ok
+}
+
+// Platforms returns every registered platform.
+func (r *Registry) Platforms() []*Platform {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	platforms := make([]*Platform, 0, len(r.platforms))
+	for _, p := range r.platforms {
+		platforms = append(platforms, p)
+	}
+	return platforms
+}
+
+// Reauthenticate is a ReauthFunc renewing tokens of the platform of the call.
+func (r *Registry) Reauthenticate(ctx context.Context, param, stale string) (string, error) {
+	componentAppID, ok := ComponentAppIDFromContext(ctx)
+	if !ok {
+		return "", errors.New("no component appid in context")
+	}
+	p, ok := r.Platform(componentAppID)
+	if !ok {
+		return "", fmt.Errorf("platform %v is not registered", componentAppID)
+	}
+	return NewReauthFunc(p.componentToken, p.authorizers)(ctx, param, stale)
+}
+
+// resolve fills in the empty credentials of req from the platform of the call,
+// and returns the context carrying its component appid.
+func (r *Registry) resolve(ctx context.Context, req *http.Request) (context.Context, error) {
+	q := req.URL.Query()
+	componentAppID, ok := ComponentAppIDFromContext(ctx)
+	if !ok {
+		componentAppID = q.Get("component_appid")
+	}
+	p, ok := r.Platform(componentAppID)
+	if !ok {
+		return ctx, nil
+	}
+	ctx = p.Context(ctx)
+
+	var changed bool
+	fill := func(param string, value func() (string, error)) error {
+		if vs, ok := q[param]; !ok || len(vs) != 1 || vs[0] != "" {
+			return nil
+		}
+		v, err := value()
+		if err != nil {
+			return err
+		}
+		q.Set(param, v)
+		changed = true
+		return nil
+	}
+	static := func(v string) func() (string, error) {
+		return func() (string, error) { return v, nil }
+	}
+
+	if err := fill("component_appid", static(p.ComponentAppID)); err != nil {
+		return ctx, err
+	}
+	if err := fill("component_appsecret", static(p.ComponentAppSecret)); err != nil {
+		return ctx, err
+	}
+	if p.Tickets != nil {
+		if err := fill("component_ticket", func() (string, error) { return p.Tickets.Ticket(ctx) }); err != nil {
+			return ctx, err
+		}
+	}
+	if err := fill(ParamComponentAccessToken, func() (string, error) {
+		return p.componentToken.Token(ctx)
+	}); err != nil {
+		return ctx, err
+	}
+	if authorizerAppID, ok := AuthorizerAppIDFromContext(ctx); ok {
+		if err := fill(ParamAuthorizerAccessToken, func() (string, error) {
+			return p.authorizers.Token(ctx, authorizerAppID)
+		}); err != nil {
+			return ctx, err
+		}
+	}
+
+	if changed {
+		u := *req.URL
+		u.RawQuery = q.Encode()
+		req.URL = &u
+	}
+	return ctx, nil
+}
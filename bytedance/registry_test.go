@@ -0,0 +1,137 @@
+package bytedance_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+// setupRegistry returns a client of s resolving the credentials of the
+// platform of s from a Registry, and the store of the client.
+func setupRegistry(t *testing.T, s *bytedancetest.Server, tickets bytedance.TicketSource) (
+	*bytedance.Client, *bytedance.Registry, *bytedance.Platform, bytedance.TokenStore) {
+	t.Helper()
+	store := bytedance.NewMemoryTokenStore()
+	c := s.Client(bytedance.WithTokenStore(store))
+	r := bytedance.NewRegistry(c)
+	p, err := r.Register(&bytedance.Platform{
+		ComponentAppID:     bytedancetest.ComponentAppID,
+		ComponentAppSecret: bytedancetest.ComponentAppSecret,
+		Tickets:            tickets,
+	})
+	if err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	return c, r, p, store
+}
+
+func TestRegistry_resolveComponent(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, _, p, _ := setupRegistry(t, s, bytedance.StaticTicket(s.Ticket()))
+	ctx := context.Background()
+
+	// the platform is selected by the context, or by the component_appid query.
+	if _, _, err := c.ThirdParty.GetTemplates(p.Context(ctx), "", ""); err != nil {
+		t.Errorf("GetTemplates with platform in context returned error: %v", err)
+	}
+	if _, _, err := c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, ""); err != nil {
+		t.Errorf("GetTemplates with component_appid returned error: %v", err)
+	}
+
+	// calls of unregistered platforms are sent as they are.
+	_, _, err := c.ThirdParty.GetTemplates(ctx, "unregistered", "")
+	if !errors.Is(err, bytedance.ErrInvalidParameter) {
+		t.Errorf("GetTemplates of unregistered platform returned error %v, want ErrInvalidParameter", err)
+	}
+}
+
+func TestRegistry_ticketFromStore(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, _, p, store := setupRegistry(t, s, nil)
+	ctx := p.Context(context.Background())
+	if err := bytedance.NewTicketKeeper(bytedancetest.ComponentAppID, store).SetTicket(ctx, s.Ticket()); err != nil {
+		t.Fatalf("SetTicket returned error: %v", err)
+	}
+
+	v, _, err := c.ThirdParty.GetComponentAccessToken(ctx, "", "", "")
+	if err != nil {
+		t.Fatalf("GetComponentAccessToken returned error: %v", err)
+	}
+	saved, err := store.GetComponentAccessToken(ctx, bytedancetest.ComponentAppID)
+	if err != nil || saved.Value != v.ComponentAccessToken {
+		t.Errorf("TokenStore has %+v, %v, want %v", saved, err, v.ComponentAccessToken)
+	}
+	if _, err := store.GetComponentAccessToken(ctx, ""); err != bytedance.ErrTokenNotFound {
+		t.Errorf("TokenStore has a token of empty component appid, error %v", err)
+	}
+
+	appID, code := s.AddAuthorizer("app")
+	if _, _, err := c.ThirdParty.GetOAuthToken(ctx, "", "", code, bytedance.GrantTypeAuthorizationCode); err != nil {
+		t.Fatalf("GetOAuthToken returned error: %v", err)
+	}
+	if _, err := store.GetAuthorizerToken(ctx, bytedancetest.ComponentAppID, appID); err != nil {
+		t.Errorf("GetAuthorizerToken returned error: %v", err)
+	}
+}
+
+func TestRegistry_resolveAuthorizer(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, _, p, _ := setupRegistry(t, s, bytedance.StaticTicket(s.Ticket()))
+	appID, code := s.AddAuthorizer("app")
+	ctx := p.Context(context.Background())
+	if _, err := p.Authorizers().Authorize(ctx, code); err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+
+	info, _, err := c.MicroApp.GetAppInfo(bytedance.WithAuthorizerAppID(ctx, appID), "", "")
+	if err != nil {
+		t.Fatalf("GetAppInfo returned error: %v", err)
+	}
+	if info.AppID != appID {
+		t.Errorf("GetAppInfo returned appid %v, want %v", info.AppID, appID)
+	}
+
+	// without the authorizer appid the token is not filled in.
+	if _, _, err := c.MicroApp.GetAppInfo(ctx, "", ""); !errors.Is(err, bytedance.ErrInvalidToken) {
+		t.Errorf("GetAppInfo without authorizer returned error %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRegistry_Reauthenticate(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	c, r, p, _ := setupRegistry(t, s, bytedance.StaticTicket(s.Ticket()))
+	c.Reauthenticate = r.Reauthenticate
+	appID, code := s.AddAuthorizer("app")
+	ctx := bytedance.WithAuthorizerAppID(p.Context(context.Background()), appID)
+	token, err := p.Authorizers().Authorize(ctx, code)
+	if err != nil {
+		t.Fatalf("Authorize returned error: %v", err)
+	}
+	componentToken, err := p.ComponentToken().Token(ctx)
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+	s.RevokeTokens()
+
+	if _, _, err := c.ThirdParty.GetTemplates(ctx, "", componentToken); err != nil {
+		t.Errorf("GetTemplates with revoked token returned error: %v", err)
+	}
+	info, _, err := c.MicroApp.GetAppInfo(ctx, "", token.AuthorizerAccessToken)
+	if err != nil {
+		t.Fatalf("GetAppInfo with revoked token returned error: %v", err)
+	}
+	if info.AppID != appID {
+		t.Errorf("GetAppInfo returned appid %v, want %v", info.AppID, appID)
+	}
+
+	if _, err := r.Reauthenticate(context.Background(), bytedance.ParamComponentAccessToken, "stale"); err == nil {
+		t.Error("Reauthenticate without platform in context returned no error")
+	}
+}
@@ -0,0 +1,133 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures how Client.Do retries failed requests.
+// Network errors, 429/502/503/504 responses and retryable errno values are
+// retried. Requests which are not idempotent, e.g. POST, are retried only if
+// their context is marked with WithIdempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, default 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts, default 5s.
+	MaxBackoff time.Duration
+
+	// Multiplier is the growth factor of the delay, default 2.
+	Multiplier float64
+
+	// Jitter is the fraction of the delay which is randomized, from 0 to 1.
+	Jitter float64
+
+	// RetryableErrNos are the errno values to retry. If nil, errors
+	// reported by IsRetryable are retried.
+	RetryableErrNos []int
+}
+
+// DefaultRetryPolicy returns a RetryPolicy of 3 attempts with jittered
+// exponential backoff.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent returns a copy of ctx marking the request as safe to retry,
+// even if its method is not idempotent.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// shouldRetry reports whether req should be retried after attempt got r and err.
+func (p *RetryPolicy) shouldRetry(req *http.Request, attempt int, r *Response, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts || !isIdempotent(req) {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	var errResp *ErrorResponse
+	switch {
+	case errors.As(err, &errResp):
+		if p.RetryableErrNos == nil {
+			return IsRetryable(err)
+		}
+		for _, errNo := range p.RetryableErrNos {
+			if errNo == errResp.ErrNo {
+				return true
+			}
+		}
+		return false
+	case err != nil:
+		// network error
+		return true
+	}
+
+	switch r.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the retry following attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, max, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d = d*(1-jitter) + d*jitter*rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// wait sleeps the backoff of attempt, or returns ctx.Err() if ctx is done first.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	t := time.NewTimer(p.backoff(attempt))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
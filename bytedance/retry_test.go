@@ -0,0 +1,171 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("backoff(2) with jitter 0.5 = %v, want within [100ms, 200ms]", got)
+		}
+	}
+}
+
+func TestRetryPolicy_backoffDefaults(t *testing.T) {
+	p := new(RetryPolicy)
+	if got := p.backoff(1); got != 100*time.Millisecond {
+		t.Errorf("backoff(1) = %v, want default 100ms", got)
+	}
+	if got := p.backoff(100); got != 5*time.Second {
+		t.Errorf("backoff(100) = %v, want default cap 5s", got)
+	}
+}
+
+// retryServer replies the responses in order, then success, and records the
+// bodies of the requests.
+type retryServer struct {
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter)
+	bodies    []string
+}
+
+func (s *retryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(b))
+	var respond func(w http.ResponseWriter)
+	if len(s.responses) > 0 {
+		respond, s.responses = s.responses[0], s.responses[1:]
+	}
+	s.mu.Unlock()
+
+	if respond != nil {
+		respond(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"errno":0,"message":"success","data":{}}`))
+}
+
+func errNoReply(errNo string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errno":` + errNo + `,"message":"error"}`))
+	}
+}
+
+func statusReply(code int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(code)
+	}
+}
+
+func setupRetry(t *testing.T, responses ...func(w http.ResponseWriter)) (*Client, *retryServer) {
+	t.Helper()
+	rs := &retryServer{responses: responses}
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	return newTestClient(t, rs, WithRetryPolicy(policy)), rs
+}
+
+func TestDo_retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		responses    []func(w http.ResponseWriter)
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"GET retried on errno -1", http.MethodGet, false,
+			[]func(http.ResponseWriter){errNoReply("-1"), errNoReply("-1")}, 3, false},
+		{"GET retried on 503", http.MethodGet, false,
+			[]func(http.ResponseWriter){statusReply(http.StatusServiceUnavailable)}, 2, false},
+		{"GET gives up after MaxAttempts", http.MethodGet, false,
+			[]func(http.ResponseWriter){errNoReply("-1"), errNoReply("-1"), errNoReply("-1")}, 3, true},
+		{"GET not retried on invalid parameter", http.MethodGet, false,
+			[]func(http.ResponseWriter){errNoReply("40001")}, 1, true},
+		{"POST not retried", http.MethodPost, false,
+			[]func(http.ResponseWriter){errNoReply("-1")}, 1, true},
+		{"POST retried with WithIdempotent", http.MethodPost, true,
+			[]func(http.ResponseWriter){errNoReply("-1"), errNoReply("-1")}, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rs := setupRetry(t, tt.responses...)
+			ctx := context.Background()
+			if tt.idempotent {
+				ctx = WithIdempotent(ctx)
+			}
+			var body interface{}
+			if tt.method == http.MethodPost {
+				body = map[string]string{"key": "value"}
+			}
+			req, err := c.NewRequest(tt.method, "v1/test", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(ctx, req, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do returned error %v, want error %v", err, tt.wantErr)
+			}
+			if resp == nil || resp.Attempts != tt.wantAttempts {
+				t.Errorf("Do returned response %+v, want %d attempts", resp, tt.wantAttempts)
+			}
+			if len(rs.bodies) != tt.wantAttempts {
+				t.Errorf("server received %d requests, want %d", len(rs.bodies), tt.wantAttempts)
+			}
+			// the body is sent again with every attempt.
+			for i, b := range rs.bodies {
+				if b != rs.bodies[0] {
+					t.Errorf("body of attempt %d is %q, want %q", i+1, b, rs.bodies[0])
+				}
+			}
+		})
+	}
+}
+
+func TestDo_retryStopsOnContextDone(t *testing.T) {
+	c, rs := setupRetry(t, errNoReply("-1"), errNoReply("-1"))
+	c.RetryPolicy.InitialBackoff = time.Hour
+	c.RetryPolicy.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := c.NewRequest(http.MethodGet, "v1/test", nil)
+	start := time.Now()
+	_, err := c.Do(ctx, req, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do returned error %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Do returned after %v, want it to stop waiting on ctx", d)
+	}
+	if len(rs.bodies) != 1 {
+		t.Errorf("server received %d requests, want 1", len(rs.bodies))
+	}
+}
@@ -0,0 +1,197 @@
+package bytedance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventHandler handles a typed push.
+type EventHandler func(ctx context.Context, event Event) error
+
+// EventMiddleware wraps the EventHandler of every push of a Router.
+type EventMiddleware func(next EventHandler) EventHandler
+
+// Router dispatches pushes to handlers by their type, see EventType. Its
+// Callback method is a CallbackFunc, e.g.
+//
+//	router := bytedance.NewRouter()
+//	router.OnComponentTicket(saveTicket)
+//	http.Handle("/callback", bytedance.NewCallbackHandler(tpToken, encodingAESKey, router.Callback))
+//
+// Errors of handlers are reported to OnError, and the push is acknowledged
+// anyway so that the platform does not push it again.
+type Router struct {
+	// OnError, when not nil, is called with the error of every failed push.
+	OnError func(ctx context.Context, event Event, err error)
+
+	mu         sync.RWMutex
+	handlers   map[string]EventHandler
+	fallback   EventHandler
+	middleware []EventMiddleware
+}
+
+// NewRouter returns a Router without handlers.
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]EventHandler)}
+}
+
+// On sets the handler of the pushes of eventType, e.g. EventPackageAudit.
+func (r *Router) On(eventType string, h EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = h
+}
+
+// OnComponentTicket sets the handler of component_ticket pushes.
+func (r *Router) OnComponentTicket(fn func(ctx context.Context, event *ComponentTicketEvent) error) {
+	r.On(MsgTypeTicket, func(ctx context.Context, event Event) error {
+		e, ok := event.(*ComponentTicketEvent)
+		if !ok {
+			return unexpectedEvent(event)
+		}
+		return fn(ctx, e)
+	})
+}
+
+// OnAuthorized sets the handler of authorized pushes.
+func (r *Router) OnAuthorized(fn func(ctx context.Context, event *AuthorizedEvent) error) {
+	r.On(EventAuthorized, func(ctx context.Context, event Event) error {
+		e, ok := event.(*AuthorizedEvent)
+		if !ok {
+			return unexpectedEvent(event)
+		}
+		return fn(ctx, e)
+	})
+}
+
+// OnUpdateAuthorized sets the handler of updateauthorized pushes.
+func (r *Router) OnUpdateAuthorized(fn func(ctx context.Context, event *UpdateAuthorizedEvent) error) {
+	r.On(EventUpdateAuthorized, func(ctx context.Context, event Event) error {
+		e, ok := event.(*UpdateAuthorizedEvent)
+		if !ok {
+			return unexpectedEvent(event)
+		}
+		return fn(ctx, e)
+	})
+}
+
+// OnUnauthorized sets the handler of unauthorized pushes.
+func (r *Router) OnUnauthorized(fn func(ctx context.Context, event *UnauthorizedEvent) error) {
+	r.On(EventUnauthorized, func(ctx context.Context, event Event) error {
+		e, ok := event.(*UnauthorizedEvent)
+		if !ok {
+			return unexpectedEvent(event)
+		}
+		return fn(ctx, e)
+	})
+}
+
+// OnAuditResult sets the handler of the audit result pushes of packages, name,
+// icon, intro and category, the type of event tells which one.
+func (r *Router) OnAuditResult(fn func(ctx context.Context, event Event, result AuditResult) error) {
+	h := func(ctx context.Context, event Event) error {
+		switch e := event.(type) {
+		case *PackageAuditEvent:
+			return fn(ctx, event, e.Content)
+		case *InfoAuditEvent:
+			return fn(ctx, event, e.Content)
+		case *CategoryAuditEvent:
+			return fn(ctx, event, e.Content)
+		}
+		return unexpectedEvent(event)
+	}
+	for _, eventType := range []string{EventPackageAudit, EventModifyAppName, EventModifyAppIcon,
+		EventModifyAppIntro, EventAppCategoryAudit} {
+		r.On(eventType, h)
+	}
+}
+
+// Fallback sets the handler of the pushes without handler, including unknown
+// ones, which are ignored if it's not set.
+func (r *Router) Fallback(h EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = h
+}
+
+// Use appends middleware wrapping the handler of every push, the first one
+// is the outermost.
+func (r *Router) Use(middleware ...EventMiddleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Dispatch calls the handler of event through the middleware.
+func (r *Router) Dispatch(ctx context.Context, event Event) error {
+	r.mu.RLock()
+	h, ok := r.handlers[event.Header().Type()]
+	if !ok {
+		h = r.fallback
+	}
+	middleware := r.middleware
+	r.mu.RUnlock()
+
+	if h == nil {
+		h = func(context.Context, Event) error { return nil }
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h(ctx, event)
+}
+
+// Callback implements CallbackFunc, it decodes msg and dispatches it. Errors
+// are reported to OnError and never returned, so that msg is acknowledged.
+func (r *Router) Callback(ctx context.Context, msg *CallbackMessage) error {
+	event, err := msg.Decode()
+	if err != nil {
+		r.report(ctx, &UnknownEvent{EventHeader: EventHeader{MsgType: msg.MsgType, Event: msg.Event}, Raw: msg.Raw}, err)
+		return nil
+	}
+	if err := r.Dispatch(ctx, event); err != nil {
+		r.report(ctx, event, err)
+	}
+	return nil
+}
+
+func (r *Router) report(ctx context.Context, event Event, err error) {
+	if r.OnError != nil {
+		r.OnError(ctx, event, err)
+	}
+}
+
+func unexpectedEvent(event Event) error {
+	return fmt.Errorf("unexpected %T of %v push", event, event.Header().Type())
+}
+
+// LoggingMiddleware returns an EventMiddleware logging every push to logger.
+func LoggingMiddleware(logger Logger) EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+			header := event.Header()
+			logger.Printf("bytedance: push %v appid=%v duration=%v error=%v",
+				header.Type(), header.AppID, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// RecoverMiddleware returns an EventMiddleware turning a panic of a handler
+// into an error.
+func RecoverMiddleware() EventMiddleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event Event) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("panic handling %v push: %v", event.Header().Type(), p)
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouter_Dispatch(t *testing.T) {
+	r := NewRouter()
+	var got []string
+	r.OnComponentTicket(func(_ context.Context, e *ComponentTicketEvent) error {
+		got = append(got, "ticket "+e.Ticket)
+		return nil
+	})
+	r.OnAuthorized(func(_ context.Context, e *AuthorizedEvent) error {
+		got = append(got, "authorized "+e.AuthorizationCode)
+		return nil
+	})
+	r.OnUnauthorized(func(_ context.Context, e *UnauthorizedEvent) error {
+		got = append(got, "unauthorized "+e.AppID)
+		return nil
+	})
+	r.OnAuditResult(func(_ context.Context, event Event, result AuditResult) error {
+		got = append(got, event.Header().Type()+" "+result.Reason)
+		return nil
+	})
+	ctx := context.Background()
+
+	events := []Event{
+		&ComponentTicketEvent{EventHeader: EventHeader{MsgType: MsgTypeTicket}, Ticket: "t"},
+		&AuthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventAuthorized}, AuthorizationCode: "c"},
+		&UnauthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventUnauthorized, AppID: "tt"}},
+		&PackageAuditEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventPackageAudit},
+			Content: AuditResult{Reason: "r1"}},
+		&InfoAuditEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventModifyAppIcon},
+			Content: AuditResult{Reason: "r2"}},
+		// pushes without handler are ignored.
+		&UpdateAuthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventUpdateAuthorized}},
+	}
+	for _, event := range events {
+		if err := r.Dispatch(ctx, event); err != nil {
+			t.Errorf("Dispatch of %v returned error: %v", event.Header().Type(), err)
+		}
+	}
+	want := []string{"ticket t", "authorized c", "unauthorized tt", "PACKAGE_AUDIT r1", "MODIFY_APP_ICON r2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handlers got %q, want %q", got, want)
+	}
+
+	// a typed handler rejects an Event of another type registered for its push.
+	err := r.Dispatch(ctx, &UnknownEvent{EventHeader: EventHeader{MsgType: MsgTypeTicket}})
+	if err == nil || !strings.Contains(err.Error(), "unexpected") {
+		t.Errorf("Dispatch of unexpected event returned error %v", err)
+	}
+}
+
+func TestRouter_Fallback(t *testing.T) {
+	r := NewRouter()
+	var handled, fallback []string
+	r.On(EventAuthorized, func(_ context.Context, event Event) error {
+		handled = append(handled, event.Header().Type())
+		return nil
+	})
+	r.Fallback(func(_ context.Context, event Event) error {
+		fallback = append(fallback, event.Header().Type())
+		return nil
+	})
+	ctx := context.Background()
+	_ = r.Dispatch(ctx, &AuthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventAuthorized}})
+	_ = r.Dispatch(ctx, &UnknownEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: "NEW_EVENT"}})
+	_ = r.Dispatch(ctx, &ComponentTicketEvent{EventHeader: EventHeader{MsgType: MsgTypeTicket}})
+
+	if want := []string{EventAuthorized}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handler got %q, want %q", handled, want)
+	}
+	if want := []string{"NEW_EVENT", MsgTypeTicket}; !reflect.DeepEqual(fallback, want) {
+		t.Errorf("fallback got %q, want %q", fallback, want)
+	}
+}
+
+func TestRouter_Use(t *testing.T) {
+	r := NewRouter()
+	var calls []string
+	trace := func(name string) EventMiddleware {
+		return func(next EventHandler) EventHandler {
+			return func(ctx context.Context, event Event) error {
+				calls = append(calls, name+" in")
+				err := next(ctx, event)
+				calls = append(calls, name+" out")
+				return err
+			}
+		}
+	}
+	r.Use(trace("first"), trace("second"))
+	r.Use(trace("third"))
+	r.On(EventAuthorized, func(context.Context, Event) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	_ = r.Dispatch(context.Background(), &AuthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventAuthorized}})
+
+	want := []string{"first in", "second in", "third in", "handler", "third out", "second out", "first out"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls are %q, want %q", calls, want)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.Use(RecoverMiddleware())
+	r.On(EventAuthorized, func(context.Context, Event) error {
+		panic("boom")
+	})
+	err := r.Dispatch(context.Background(), &AuthorizedEvent{EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventAuthorized}})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Dispatch returned error %v, want the panic", err)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	logger := new(bufferLogger)
+	r := NewRouter()
+	r.Use(LoggingMiddleware(logger))
+	r.On(EventAuthorized, func(context.Context, Event) error { return errors.New("failed") })
+	_ = r.Dispatch(context.Background(), &AuthorizedEvent{
+		EventHeader: EventHeader{MsgType: MsgTypeEvent, Event: EventAuthorized, AppID: "tt_app"}})
+	if log := logger.String(); !strings.Contains(log, "authorized appid=tt_app") || !strings.Contains(log, "failed") {
+		t.Errorf("log is %q", log)
+	}
+}
+
+func TestRouter_Callback(t *testing.T) {
+	r := NewRouter()
+	var reported []string
+	r.OnError = func(_ context.Context, event Event, err error) {
+		reported = append(reported, event.Header().Type()+": "+err.Error())
+	}
+	r.On(EventAuthorized, func(context.Context, Event) error { return errors.New("failed") })
+	var ticket string
+	r.OnComponentTicket(func(_ context.Context, e *ComponentTicketEvent) error {
+		ticket = e.Ticket
+		return nil
+	})
+	ctx := context.Background()
+
+	msgs := []*CallbackMessage{
+		{MsgType: MsgTypeTicket, Raw: json.RawMessage(`{"MsgType":"Ticket","Ticket":"t"}`)},
+		{MsgType: MsgTypeEvent, Event: EventAuthorized, Raw: json.RawMessage(`{"MsgType":"Event","Event":"authorized"}`)},
+		{MsgType: MsgTypeEvent, Event: EventPackageAudit,
+			Raw: json.RawMessage(`{"MsgType":"Event","Event":"PACKAGE_AUDIT","Content":"invalid"}`)},
+	}
+	// every push is acknowledged.
+	for _, msg := range msgs {
+		if err := r.Callback(ctx, msg); err != nil {
+			t.Errorf("Callback of %v returned error: %v", msg.Event, err)
+		}
+	}
+	if ticket != "t" {
+		t.Errorf("ticket handler got %q, want t", ticket)
+	}
+	if len(reported) != 2 || !strings.HasPrefix(reported[0], "authorized: failed") ||
+		!strings.HasPrefix(reported[1], EventPackageAudit+": ") {
+		t.Errorf("OnError got %q, want the failed handler and the undecodable push", reported)
+	}
+}
@@ -2,8 +2,8 @@ package bytedance
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Template 模版
@@ -21,12 +21,11 @@ type Templates struct {
 
 // GetTemplates 获取第三方应用的所有模版
 func (s *ThirdPartyService) GetTemplates(ctx context.Context, componentAppID, componentAccessToken string) (
-	*Templates, *http.Response, error) {
-	u := fmt.Sprintf(
-		"v1/tp/template/get_tpl_list?component_appid=%v&component_access_token=%v",
-		componentAppID,
-		componentAccessToken,
-	)
+	*Templates, *Response, error) {
+	u := withQuery("v1/tp/template/get_tpl_list", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -55,12 +54,11 @@ type Drafts struct {
 
 // GetDrafts 获取第三方应用的草稿
 func (s *ThirdPartyService) GetDrafts(ctx context.Context, componentAppID, componentAccessToken string) (
-	*Drafts, *http.Response, error) {
-	u := fmt.Sprintf(
-		"v1/tp/template/get_draft_list?component_appid=%v&component_access_token=%v",
-		componentAppID,
-		componentAccessToken,
-	)
+	*Drafts, *Response, error) {
+	u := withQuery("v1/tp/template/get_draft_list", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -82,11 +80,11 @@ type AddTemplateRequest struct {
 // AddTemplate adds a template from draft.
 // 将临时草稿设置为持久的代码模板。每个第三方应用的模板上限为200个。
 func (s *ThirdPartyService) AddTemplate(ctx context.Context, componentAppID, componentAccessToken string,
-	body *AddTemplateRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/tp/template/add_tpl?component_appid=%v&component_access_token=%v",
-		componentAppID,
-		componentAccessToken,
-	)
+	body *AddTemplateRequest) (*Response, error) {
+	u := withQuery("v1/tp/template/add_tpl", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -103,11 +101,11 @@ type DeleteTemplateRequest struct {
 // DeleteTemplate deletes a template from draft.
 // 将临时草稿设置为持久的代码模板。每个第三方应用的模板上限为200个。
 func (s *ThirdPartyService) DeleteTemplate(ctx context.Context, componentAppID, componentAccessToken string,
-	body *DeleteTemplateRequest) (*http.Response, error) {
-	u := fmt.Sprintf("v1/tp/template/del_tpl?component_appid=%v&component_access_token=%v",
-		componentAppID,
-		componentAccessToken,
-	)
+	body *DeleteTemplateRequest) (*Response, error) {
+	u := withQuery("v1/tp/template/del_tpl", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
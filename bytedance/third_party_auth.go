@@ -2,8 +2,9 @@ package bytedance
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 // ComponentAccessToken is response of API v1/auth/tp/token.
@@ -15,10 +16,26 @@ type ComponentAccessToken struct {
 // GetComponentAccessToken gets a component_access_token.
 // 获取第三方平台 component_access_token
 // 每个令牌有效期是 2 小时
+// An empty componentAppID is taken from the context, see WithComponentAppID.
+// An empty componentTicket is supplied by the registered Platform, or read from
+// the TokenStore of the Client, see TicketKeeper. If the Client has a
+// TokenStore, the returned token is saved to it.
 func (s *ThirdPartyService) GetComponentAccessToken(ctx context.Context, componentAppID, componentAppSecret,
-	componentTicket string) (*ComponentAccessToken, *http.Response, error) {
-	u := fmt.Sprintf("v1/auth/tp/token?component_appid=%v&component_appsecret=%v&component_ticket=%v",
-		componentAppID, componentAppSecret, componentTicket)
+	componentTicket string) (*ComponentAccessToken, *Response, error) {
+	componentAppID = componentAppIDOf(ctx, componentAppID)
+	store := s.client.TokenStore
+	if componentTicket == "" {
+		var err error
+		if componentTicket, err = s.componentTicket(ctx, componentAppID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	u := withQuery("v1/auth/tp/token", url.Values{
+		"component_appid":     {componentAppID},
+		"component_appsecret": {componentAppSecret},
+		"component_ticket":    {componentTicket},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -26,13 +43,39 @@ func (s *ThirdPartyService) GetComponentAccessToken(ctx context.Context, compone
 	}
 
 	v := new(ComponentAccessToken)
+	start := time.Now()
 	resp, err := s.client.Do(ctx, req, v)
 	if err != nil {
 		return nil, resp, err
 	}
+	if store != nil {
+		token := &Token{
+			Value:     v.ComponentAccessToken,
+			ExpiresAt: start.Add(time.Duration(v.ExpiresIn) * time.Second),
+		}
+		// the token is valid even if it can not be saved.
+		if err := store.SetComponentAccessToken(ctx, componentAppID, token); err != nil {
+			return v, resp, err
+		}
+	}
 	return v, resp, nil
 }
 
+// componentTicket returns the ticket of componentAppID from the TicketSource of
+// its registered Platform, or else from the TokenStore. It's empty if there is
+// neither.
+func (s *ThirdPartyService) componentTicket(ctx context.Context, componentAppID string) (string, error) {
+	if r := s.client.Platforms; r != nil {
+		if p, ok := r.Platform(componentAppID); ok && p.Tickets != nil {
+			return p.Tickets.Ticket(ctx)
+		}
+	}
+	if store := s.client.TokenStore; store != nil {
+		return NewTicketKeeper(componentAppID, store).Ticket(ctx)
+	}
+	return "", nil
+}
+
 // CreatePreAuthCodeRequest 创建预授权码请求body
 type CreatePreAuthCodeRequest struct {
 	ShareRatio  int `json:"share_ratio"`
@@ -49,9 +92,11 @@ type PreAuthCode struct {
 // 用于获取预授权码，预授权码用于小程序授权时的第三方平台方安全验证。
 // 每个预授权码有效期为 10 分钟。
 func (s *ThirdPartyService) CreatePreAuthCode(ctx context.Context, componentAccessToken,
-	componentAppID string, body *CreatePreAuthCodeRequest) (*PreAuthCode, *http.Response, error) {
-	u := fmt.Sprintf("v2/auth/pre_auth_code?component_access_token=%v&component_appid=%v",
-		componentAccessToken, componentAppID)
+	componentAppID string, body *CreatePreAuthCodeRequest) (*PreAuthCode, *Response, error) {
+	u := withQuery("v2/auth/pre_auth_code", url.Values{
+		"component_access_token": {componentAccessToken},
+		"component_appid":        {componentAppID},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, body)
 	if err != nil {
@@ -87,25 +132,39 @@ type OAuthToken struct {
 // 使用授权码换取小程序的接口调用凭据
 // authorizer_access_token 有效期 2 小时
 // authorizer_refresh_token 有效期 1 个月，且只可使用一次，使用后失效
+// An empty componentAppID is taken from the context, see WithComponentAppID.
+// If the Client has a TokenStore, the returned tokens are saved to it.
 func (s *ThirdPartyService) GetOAuthToken(ctx context.Context, componentAppID, componentAccessToken,
-	authorizationCode, grantType string) (*OAuthToken, *http.Response, error) {
-	u := fmt.Sprintf(
-		"v1/oauth/token?component_appid=%v&component_access_token=%v&authorization_code=%v&grant_type=%v",
-		componentAppID,
-		componentAccessToken,
-		authorizationCode,
-		grantType,
-	)
+	authorizationCode, grantType string) (*OAuthToken, *Response, error) {
+	componentAppID = componentAppIDOf(ctx, componentAppID)
+	u := withQuery("v1/oauth/token", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+		"authorization_code":     {authorizationCode},
+		"grant_type":             {grantType},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 	oAuthToken := new(OAuthToken)
+	start := time.Now()
 	resp, err := s.client.Do(ctx, req, oAuthToken)
 	if err != nil {
 		return nil, resp, err
 	}
+	if store := s.client.TokenStore; store != nil {
+		token := &AuthorizerToken{
+			AccessToken:  oAuthToken.AuthorizerAccessToken,
+			RefreshToken: oAuthToken.AuthorizerRefreshToken,
+			ExpiresAt:    start.Add(time.Duration(oAuthToken.ExpiresIn) * time.Second),
+		}
+		// the tokens are valid even if they can not be saved.
+		if err := store.SetAuthorizerToken(ctx, componentAppID, oAuthToken.AuthorizerAppID, token); err != nil {
+			return oAuthToken, resp, err
+		}
+	}
 	return oAuthToken, resp, nil
 
 }
@@ -119,15 +178,18 @@ type RefreshOAuthTokenResponse struct {
 
 // RefreshOAuthToken refresh authorizer_access_token
 // 刷新授权小程序的接口调用凭据
+// authorizerRefreshToken is used up by the call, and the rotated tokens are not
+// saved to the TokenStore of the Client since the authorizer appid is unknown
+// here. Callers must persist them before using them, AuthorizerTokenManager
+// does so and should be preferred.
 func (s *ThirdPartyService) RefreshOAuthToken(ctx context.Context, componentAppID, componentAccessToken,
-	authorizerRefreshToken, grantType string) (*RefreshOAuthTokenResponse, *http.Response, error) {
-	u := fmt.Sprintf(
-		"v1/oauth/token?component_appid=%v&component_access_token=%v&authorizer_refresh_token=%v&grant_type=%v",
-		componentAppID,
-		componentAccessToken,
-		authorizerRefreshToken,
-		grantType,
-	)
+	authorizerRefreshToken, grantType string) (*RefreshOAuthTokenResponse, *Response, error) {
+	u := withQuery("v1/oauth/token", url.Values{
+		"component_appid":          {componentAppID},
+		"component_access_token":   {componentAccessToken},
+		"authorizer_refresh_token": {authorizerRefreshToken},
+		"grant_type":               {grantType},
+	})
 
 	req, err := s.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
@@ -151,13 +213,12 @@ type RetrieveAuthorizationCodeResponse struct {
 // RetrieveAuthorizationCode retrieve authorizer_access_token.
 // 找回授权码 补偿机制
 func (s *ThirdPartyService) RetrieveAuthorizationCode(ctx context.Context, componentAppID, componentAccessToken,
-	authorizationAppID string) (*RetrieveAuthorizationCodeResponse, *http.Response, error) {
-	u := fmt.Sprintf(
-		"v1/auth/retrieve?component_appid=%v&component_access_token=%v&authorization_appid=%v",
-		componentAppID,
-		componentAccessToken,
-		authorizationAppID,
-	)
+	authorizationAppID string) (*RetrieveAuthorizationCodeResponse, *Response, error) {
+	u := withQuery("v1/auth/retrieve", url.Values{
+		"component_appid":        {componentAppID},
+		"component_access_token": {componentAccessToken},
+		"authorization_appid":    {authorizationAppID},
+	})
 
 	req, err := s.client.NewRequest(http.MethodPost, u, nil)
 	if err != nil {
@@ -0,0 +1,110 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTicketValidity is how long a pushed component_ticket is valid by default.
+const DefaultTicketValidity = 2 * time.Hour
+
+// ErrNoTicket is returned if no component_ticket was pushed within its validity.
+var ErrNoTicket = errors.New("no valid component_ticket")
+
+// TicketKeeper keeps the latest component_ticket pushed by the platform in a
+// TokenStore, and supplies it as a TicketSource, e.g. to ComponentTokenSource.
+// Since GetComponentAccessToken reads an empty ticket from the TokenStore of
+// the Client, sharing the TokenStore feeds the ticket into it as well.
+//
+// Tickets are ingested by HandleTicket, e.g. with Router.OnComponentTicket, or
+// by Callback as the CallbackFunc of a CallbackHandler.
+type TicketKeeper struct {
+	componentAppID string
+	store          TokenStore
+
+	// Validity is how long a received ticket is valid.
+	// Zero means DefaultTicketValidity.
+	Validity time.Duration
+}
+
+// NewTicketKeeper returns a TicketKeeper of the tickets of componentAppID
+// persisted in store, a MemoryTokenStore if store is nil.
+func NewTicketKeeper(componentAppID string, store TokenStore) *TicketKeeper {
+	if store == nil {
+		store = NewMemoryTokenStore()
+	}
+	return &TicketKeeper{componentAppID: componentAppID, store: store}
+}
+
+// Ticket implements TicketSource interface. It returns an error matching
+// ErrNoTicket if no ticket was received within its validity.
+func (k *TicketKeeper) Ticket(ctx context.Context) (string, error) {
+	ticket, err := k.store.GetComponentTicket(ctx, k.componentAppID)
+	if err == ErrTokenNotFound {
+		return "", fmt.Errorf("component %v: %w", k.componentAppID, ErrNoTicket)
+	}
+	if err != nil {
+		return "", err
+	}
+	// tickets saved without expiry never expire.
+	if !ticket.ExpiresAt.IsZero() && !ticket.Valid(0) {
+		return "", fmt.Errorf("component %v: ticket expired at %v: %w",
+			k.componentAppID, ticket.ExpiresAt.Format(time.RFC3339), ErrNoTicket)
+	}
+	return ticket.Value, nil
+}
+
+// SetTicket saves ticket received now.
+func (k *TicketKeeper) SetTicket(ctx context.Context, ticket string) error {
+	if ticket == "" {
+		return errors.New("component_ticket is empty")
+	}
+	return k.store.SetComponentTicket(ctx, k.componentAppID, &Token{
+		Value:     ticket,
+		ExpiresAt: time.Now().Add(k.validity()),
+	})
+}
+
+// HandleTicket saves the ticket of event, it can be used with Router.OnComponentTicket.
+// It returns an error matching ErrAppIDMismatch if event has the appid of
+// another component.
+func (k *TicketKeeper) HandleTicket(ctx context.Context, event *ComponentTicketEvent) error {
+	if event.AppID != "" && event.AppID != k.componentAppID {
+		return k.mismatch(event.AppID)
+	}
+	return k.SetTicket(ctx, event.Ticket)
+}
+
+// Callback implements CallbackFunc, it saves the ticket of component_ticket
+// pushes and ignores others. A ticket pushed to another component is rejected
+// with an error matching ErrAppIDMismatch.
+func (k *TicketKeeper) Callback(ctx context.Context, msg *CallbackMessage) error {
+	if EventType(msg.MsgType, msg.Event) != MsgTypeTicket {
+		return nil
+	}
+	if msg.AppID != k.componentAppID {
+		return k.mismatch(msg.AppID)
+	}
+	event, err := msg.Decode()
+	if err != nil {
+		return err
+	}
+	e, ok := event.(*ComponentTicketEvent)
+	if !ok {
+		return unexpectedEvent(event)
+	}
+	return k.HandleTicket(ctx, e)
+}
+
+func (k *TicketKeeper) mismatch(appID string) error {
+	return fmt.Errorf("component %v: ticket pushed to %q: %w", k.componentAppID, appID, ErrAppIDMismatch)
+}
+
+func (k *TicketKeeper) validity() time.Duration {
+	if k.Validity > 0 {
+		return k.Validity
+	}
+	return DefaultTicketValidity
+}
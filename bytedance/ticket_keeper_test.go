@@ -0,0 +1,112 @@
+package bytedance_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+// ticketPush returns the decrypted push of ticket to componentAppID.
+func ticketPush(componentAppID, ticket string) *bytedance.CallbackMessage {
+	raw, _ := json.Marshal(map[string]string{"MsgType": bytedance.MsgTypeTicket, "Ticket": ticket})
+	return &bytedance.CallbackMessage{MsgType: bytedance.MsgTypeTicket, AppID: componentAppID, Raw: raw}
+}
+
+func TestTicketKeeper_Ticket(t *testing.T) {
+	store := bytedance.NewMemoryTokenStore()
+	k := bytedance.NewTicketKeeper("component", store)
+	ctx := context.Background()
+
+	if _, err := k.Ticket(ctx); !errors.Is(err, bytedance.ErrNoTicket) {
+		t.Errorf("Ticket without push returned error %v, want ErrNoTicket", err)
+	}
+	if err := k.SetTicket(ctx, ""); err == nil {
+		t.Error("SetTicket of empty ticket returned no error")
+	}
+	if err := k.SetTicket(ctx, "ticket"); err != nil {
+		t.Fatalf("SetTicket returned error: %v", err)
+	}
+	if ticket, err := k.Ticket(ctx); err != nil || ticket != "ticket" {
+		t.Errorf("Ticket returned %v, %v, want ticket", ticket, err)
+	}
+
+	_ = store.SetComponentTicket(ctx, "component", &bytedance.Token{Value: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	if _, err := k.Ticket(ctx); !errors.Is(err, bytedance.ErrNoTicket) {
+		t.Errorf("Ticket of expired ticket returned error %v, want ErrNoTicket", err)
+	}
+
+	// tickets saved without expiry never expire.
+	_ = store.SetComponentTicket(ctx, "component", &bytedance.Token{Value: "forever"})
+	if ticket, err := k.Ticket(ctx); err != nil || ticket != "forever" {
+		t.Errorf("Ticket returned %v, %v, want forever", ticket, err)
+	}
+}
+
+func TestTicketKeeper_Validity(t *testing.T) {
+	store := bytedance.NewMemoryTokenStore()
+	k := bytedance.NewTicketKeeper("component", store)
+	k.Validity = time.Minute
+	ctx := context.Background()
+	if err := k.SetTicket(ctx, "ticket"); err != nil {
+		t.Fatalf("SetTicket returned error: %v", err)
+	}
+	saved, err := store.GetComponentTicket(ctx, "component")
+	if err != nil {
+		t.Fatalf("GetComponentTicket returned error: %v", err)
+	}
+	if d := time.Until(saved.ExpiresAt); d <= 0 || d > time.Minute {
+		t.Errorf("ticket expires in %v, want within a minute", d)
+	}
+}
+
+func TestTicketKeeper_Callback(t *testing.T) {
+	store := bytedance.NewMemoryTokenStore()
+	k := bytedance.NewTicketKeeper("component", store)
+	ctx := context.Background()
+
+	authorized := &bytedance.CallbackMessage{MsgType: bytedance.MsgTypeEvent, Event: bytedance.EventAuthorized,
+		AppID: "component", Raw: json.RawMessage(`{"MsgType":"Event","Event":"authorized"}`)}
+	if err := k.Callback(ctx, authorized); err != nil {
+		t.Errorf("Callback of authorized push returned error: %v", err)
+	}
+	if err := k.Callback(ctx, ticketPush("other", "ticket")); !errors.Is(err, bytedance.ErrAppIDMismatch) {
+		t.Errorf("Callback of push to other component returned error %v, want ErrAppIDMismatch", err)
+	}
+	if _, err := k.Ticket(ctx); !errors.Is(err, bytedance.ErrNoTicket) {
+		t.Errorf("Ticket after ignored pushes returned error %v, want ErrNoTicket", err)
+	}
+
+	if err := k.Callback(ctx, ticketPush("component", "ticket")); err != nil {
+		t.Fatalf("Callback returned error: %v", err)
+	}
+	if ticket, err := k.Ticket(ctx); err != nil || ticket != "ticket" {
+		t.Errorf("Ticket returned %v, %v, want ticket", ticket, err)
+	}
+}
+
+func TestTicketKeeper_sharedStore(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	store := bytedance.NewMemoryTokenStore()
+	c := s.Client(bytedance.WithTokenStore(store))
+	k := bytedance.NewTicketKeeper(bytedancetest.ComponentAppID, store)
+	ctx := context.Background()
+
+	if err := k.Callback(ctx, ticketPush(bytedancetest.ComponentAppID, s.Ticket())); err != nil {
+		t.Fatalf("Callback returned error: %v", err)
+	}
+	// the pushed ticket is read from the store of the client.
+	if _, _, err := c.ThirdParty.GetComponentAccessToken(ctx, bytedancetest.ComponentAppID,
+		bytedancetest.ComponentAppSecret, ""); err != nil {
+		t.Errorf("GetComponentAccessToken returned error: %v", err)
+	}
+	ts := bytedance.NewComponentTokenSource(c, bytedancetest.ComponentAppID, bytedancetest.ComponentAppSecret, k)
+	if _, err := ts.Refresh(ctx); err != nil {
+		t.Errorf("Refresh with TicketKeeper returned error: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultRefreshMargin is how long before expiry a cached token is refreshed.
+const defaultRefreshMargin = 5 * time.Minute
+
+// defaultFetchTimeout bounds a shared refresh, which is detached from the
+// cancellation of its callers.
+const defaultFetchTimeout = 30 * time.Second
+
+// TokenSource supplies an access token for API calls.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TicketSource supplies the latest component_ticket pushed by the platform.
+type TicketSource interface {
+	Ticket(ctx context.Context) (string, error)
+}
+
+// TicketSourceFunc adapts an ordinary function to the TicketSource interface.
+type TicketSourceFunc func(ctx context.Context) (string, error)
+
+// Ticket implements TicketSource interface.
+func (f TicketSourceFunc) Ticket(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticTicket is a TicketSource always returning the same component_ticket.
+type StaticTicket string
+
+// Ticket implements TicketSource interface.
+func (t StaticTicket) Ticket(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// tokenCall is an in-flight refresh shared by concurrent callers, force is set
+// if it skips the token saved to the TokenStore.
+type tokenCall struct {
+	done  chan struct{}
+	force bool
+	token string
+	err   error
+}
+
+// ComponentTokenSource is a concurrency-safe TokenSource of component_access_token.
+// The token is cached and refreshed RefreshMargin before it expires, concurrent
+// refreshes are collapsed into a single request. If the Client has a TokenStore,
+// tokens saved by other processes are reused.
+type ComponentTokenSource struct {
+	client             *Client
+	componentAppID     string
+	componentAppSecret string
+	tickets            TicketSource
+
+	// RefreshMargin is how long before expiry the token is refreshed.
+	// Zero means defaultRefreshMargin.
+	RefreshMargin time.Duration
+
+	// FetchTimeout is how long a refresh may take, since callers leaving do
+	// not cancel it. Zero means defaultFetchTimeout.
+	FetchTimeout time.Duration
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	inflight  *tokenCall
+}
+
+// NewComponentTokenSource returns a ComponentTokenSource fetching tokens through c.
+func NewComponentTokenSource(c *Client, componentAppID, componentAppSecret string,
+	tickets TicketSource) *ComponentTokenSource {
+	return &ComponentTokenSource{
+		client:             c,
+		componentAppID:     componentAppID,
+		componentAppSecret: componentAppSecret,
+		tickets:            tickets,
+	}
+}
+
+// ComponentAppID returns the component appid of the token source.
+func (ts *ComponentTokenSource) ComponentAppID() string {
+	return ts.componentAppID
+}
+
+// Token returns a valid component_access_token, refreshing it if necessary.
+func (ts *ComponentTokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	if ts.token != "" && time.Now().Add(ts.margin()).Before(ts.expiresAt) {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+	return ts.refreshLocked(ctx, false)
+}
+
+// Refresh fetches a new component_access_token regardless of the cached one.
+func (ts *ComponentTokenSource) Refresh(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	ts.token = ""
+	return ts.refreshLocked(ctx, true)
+}
+
+// Renew fetches a new component_access_token if stale, a token rejected by the
+// server, is still the cached one. Otherwise the cached one is returned, so that
+// concurrent renewals of the same stale token fetch only once.
+func (ts *ComponentTokenSource) Renew(ctx context.Context, stale string) (string, error) {
+	ts.mu.Lock()
+	if ts.token != "" && ts.token != stale && time.Now().Add(ts.margin()).Before(ts.expiresAt) {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+	ts.token = ""
+	return ts.refreshLocked(ctx, true)
+}
+
+// refreshLocked starts or joins a refresh, ts.mu must be held and is released.
+// A forced refresh does not join one which may return the saved token.
+func (ts *ComponentTokenSource) refreshLocked(ctx context.Context, force bool) (string, error) {
+	call := ts.inflight
+	if call == nil || force && !call.force {
+		call = &tokenCall{done: make(chan struct{}), force: force}
+		ts.inflight = call
+		// detach from caller's cancellation so that waiters are not failed by it.
+		go ts.fetch(detach(ctx), call)
+	}
+	ts.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (ts *ComponentTokenSource) fetch(ctx context.Context, call *tokenCall) {
+	ctx, cancel := context.WithTimeout(ctx, ts.fetchTimeout())
+	defer cancel()
+	token, expiresAt, err := ts.fetchToken(ctx, call.force)
+
+	ts.mu.Lock()
+	// a call superseded by a forced one must not cache its token.
+	if ts.inflight == call {
+		if err == nil {
+			ts.token, ts.expiresAt = token, expiresAt
+		}
+		ts.inflight = nil
+	}
+	ts.mu.Unlock()
+
+	call.token, call.err = token, err
+	close(call.done)
+}
+
+// fetchToken reuses a token saved to the TokenStore by other processes unless force
+// is set, otherwise fetches a new one. Without a TicketSource the ticket is read
+// from the TokenStore.
+func (ts *ComponentTokenSource) fetchToken(ctx context.Context, force bool) (string, time.Time, error) {
+	store := ts.client.TokenStore
+	if store != nil && !force {
+		token, err := store.GetComponentAccessToken(ctx, ts.componentAppID)
+		if err != nil && err != ErrTokenNotFound {
+			return "", time.Time{}, err
+		}
+		if token.Valid(ts.margin()) {
+			return token.Value, token.ExpiresAt, nil
+		}
+	}
+
+	var ticket string
+	switch {
+	case ts.tickets != nil:
+		var err error
+		if ticket, err = ts.tickets.Ticket(ctx); err != nil {
+			return "", time.Time{}, err
+		}
+	case store == nil:
+		return "", time.Time{}, errors.New("component ticket source is nil")
+	}
+	start := time.Now()
+	v, _, err := ts.client.ThirdParty.GetComponentAccessToken(ctx, ts.componentAppID, ts.componentAppSecret, ticket)
+	if v == nil {
+		return "", time.Time{}, err
+	}
+	if err != nil && ts.client.Logger != nil {
+		// the token is valid even if it can not be saved.
+		ts.client.Logger.Printf("bytedance: save component_access_token of %v: %v", ts.componentAppID, err)
+	}
+	return v.ComponentAccessToken, start.Add(time.Duration(v.ExpiresIn) * time.Second), nil
+}
+
+func (ts *ComponentTokenSource) margin() time.Duration {
+	if ts.RefreshMargin > 0 {
+		return ts.RefreshMargin
+	}
+	return defaultRefreshMargin
+}
+
+func (ts *ComponentTokenSource) fetchTimeout() time.Duration {
+	if ts.FetchTimeout > 0 {
+		return ts.FetchTimeout
+	}
+	return defaultFetchTimeout
+}
+
+// detachedContext keeps the values of its parent but is never canceled.
+type detachedContext struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+// Deadline implements context.Context interface.
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+// Done implements context.Context interface.
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+// Err implements context.Context interface.
+func (detachedContext) Err() error { return nil }
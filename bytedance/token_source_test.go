@@ -0,0 +1,254 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setupTokenSource returns a ComponentTokenSource of a test server issuing
+// tokens valid for expiresIn seconds, and the number of tokens it issued.
+func setupTokenSource(t *testing.T, expiresIn int64, opts ...Option) (*ComponentTokenSource, *int32) {
+	t.Helper()
+	var fetches int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&fetches, 1)
+		// let concurrent callers pile up on the in-flight refresh.
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"errno":0,"message":"success","data":{"component_access_token":"token-%d","expires_in":%d}}`,
+			n, expiresIn)
+	}), opts...)
+	return NewComponentTokenSource(c, "component", "secret", StaticTicket("ticket")), &fetches
+}
+
+// concurrently calls f n times and returns the results.
+func concurrently(n int, f func() (string, error)) ([]string, []error) {
+	tokens, errs := make([]string, n), make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = f()
+		}(i)
+	}
+	wg.Wait()
+	return tokens, errs
+}
+
+func TestComponentTokenSource_Token_singleFlight(t *testing.T) {
+	ts, fetches := setupTokenSource(t, 7200)
+	ctx := context.Background()
+
+	tokens, errs := concurrently(20, func() (string, error) { return ts.Token(ctx) })
+	for i := range tokens {
+		if errs[i] != nil {
+			t.Fatalf("Token returned error: %v", errs[i])
+		}
+		if tokens[i] != "token-1" {
+			t.Errorf("Token returned %v, want token-1", tokens[i])
+		}
+	}
+	if n := atomic.LoadInt32(fetches); n != 1 {
+		t.Errorf("%d tokens fetched, want 1", n)
+	}
+
+	// the cached token is reused.
+	if token, _ := ts.Token(ctx); token != "token-1" {
+		t.Errorf("Token returned %v, want cached token-1", token)
+	}
+	if n := atomic.LoadInt32(fetches); n != 1 {
+		t.Errorf("%d tokens fetched, want 1", n)
+	}
+}
+
+func TestComponentTokenSource_Token_refreshMargin(t *testing.T) {
+	// tokens valid for a minute are within the default margin of 5 minutes.
+	ts, fetches := setupTokenSource(t, 60)
+	ctx := context.Background()
+	for _, want := range []string{"token-1", "token-2"} {
+		if token, err := ts.Token(ctx); err != nil || token != want {
+			t.Errorf("Token returned %v, %v, want %v", token, err, want)
+		}
+	}
+
+	ts.RefreshMargin = time.Second
+	if token, _ := ts.Token(ctx); token != "token-2" {
+		t.Errorf("Token returned %v, want cached token-2", token)
+	}
+	if n := atomic.LoadInt32(fetches); n != 2 {
+		t.Errorf("%d tokens fetched, want 2", n)
+	}
+}
+
+func TestComponentTokenSource_Renew(t *testing.T) {
+	ts, fetches := setupTokenSource(t, 7200)
+	ctx := context.Background()
+	stale, _ := ts.Token(ctx)
+
+	tokens, errs := concurrently(20, func() (string, error) { return ts.Renew(ctx, stale) })
+	for i := range tokens {
+		if errs[i] != nil {
+			t.Fatalf("Renew returned error: %v", errs[i])
+		}
+		if tokens[i] != "token-2" {
+			t.Errorf("Renew returned %v, want token-2", tokens[i])
+		}
+	}
+
+	// a token renewed already is not renewed again.
+	if token, _ := ts.Renew(ctx, stale); token != "token-2" {
+		t.Errorf("Renew returned %v, want token-2", token)
+	}
+	if n := atomic.LoadInt32(fetches); n != 2 {
+		t.Errorf("%d tokens fetched, want 2", n)
+	}
+
+	if token, _ := ts.Refresh(ctx); token != "token-3" {
+		t.Errorf("Refresh returned %v, want token-3", token)
+	}
+}
+
+func TestComponentTokenSource_Token_store(t *testing.T) {
+	store := NewMemoryTokenStore()
+	ts, fetches := setupTokenSource(t, 7200, WithTokenStore(store))
+	ctx := context.Background()
+	_ = store.SetComponentAccessToken(ctx, "component", &Token{Value: "shared", ExpiresAt: time.Now().Add(time.Hour)})
+
+	if token, err := ts.Token(ctx); err != nil || token != "shared" {
+		t.Errorf("Token returned %v, %v, want shared", token, err)
+	}
+	if n := atomic.LoadInt32(fetches); n != 0 {
+		t.Errorf("%d tokens fetched, want 0", n)
+	}
+
+	// refreshed tokens are saved for other processes.
+	token, _ := ts.Refresh(ctx)
+	if saved, _ := store.GetComponentAccessToken(ctx, "component"); saved.Value != token {
+		t.Errorf("TokenStore has %v, want %v", saved.Value, token)
+	}
+}
+
+func TestComponentTokenSource_Token_canceled(t *testing.T) {
+	ts, _ := setupTokenSource(t, 7200)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ts.Token(ctx); err != context.Canceled {
+		t.Errorf("Token returned error %v, want context.Canceled", err)
+	}
+
+	// the refresh started by the canceled caller is still shared.
+	if token, err := ts.Token(context.Background()); err != nil || token != "token-1" {
+		t.Errorf("Token returned %v, %v, want token-1", token, err)
+	}
+}
+
+func TestComponentTokenSource_Token_fetchTimeout(t *testing.T) {
+	var fetches int32
+	hang := make(chan struct{})
+	defer close(hang)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := atomic.AddInt32(&fetches, 1); n == 1 {
+			select {
+			case <-hang:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errno":0,"message":"success","data":{"component_access_token":"token","expires_in":7200}}`))
+	}))
+	ts := NewComponentTokenSource(c, "component", "secret", StaticTicket("ticket"))
+	ts.FetchTimeout = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := ts.Token(ctx); err == nil {
+		t.Fatal("Token of a hung fetch returned no error")
+	}
+	// the hung fetch is not joined by later calls.
+	if token, err := ts.Token(ctx); err != nil || token != "token" {
+		t.Errorf("Token returned %v, %v, want token", token, err)
+	}
+}
+
+// blockingStore is a TokenStore whose read of the component access token
+// returns only after unblock is closed.
+type blockingStore struct {
+	TokenStore
+	reading chan struct{}
+	unblock chan struct{}
+}
+
+func (s *blockingStore) GetComponentAccessToken(ctx context.Context, componentAppID string) (*Token, error) {
+	token, err := s.TokenStore.GetComponentAccessToken(ctx, componentAppID)
+	close(s.reading)
+	<-s.unblock
+	return token, err
+}
+
+func TestComponentTokenSource_Renew_doesNotJoinStoreRead(t *testing.T) {
+	store := &blockingStore{
+		TokenStore: NewMemoryTokenStore(),
+		reading:    make(chan struct{}),
+		unblock:    make(chan struct{}),
+	}
+	ctx := context.Background()
+	_ = store.SetComponentAccessToken(ctx, "component", &Token{Value: "stale", ExpiresAt: time.Now().Add(time.Hour)})
+	ts, _ := setupTokenSource(t, 7200, WithTokenStore(store))
+
+	done := make(chan string)
+	go func() {
+		token, _ := ts.Token(ctx)
+		done <- token
+	}()
+	<-store.reading
+
+	// the server rejected the saved token, which the pending read returns.
+	renewCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	token, err := ts.Renew(renewCtx, "stale")
+	if err != nil || token != "token-1" {
+		t.Errorf("Renew returned %v, %v, want token-1", token, err)
+	}
+	close(store.unblock)
+	if token := <-done; token != "stale" {
+		t.Errorf("Token returned %v, want stale of the store", token)
+	}
+	if token, _ := ts.Token(ctx); token != "token-1" {
+		t.Errorf("Token returned %v, want the renewed token-1", token)
+	}
+}
+
+// failingStore is a TokenStore failing to save component access tokens.
+type failingStore struct {
+	TokenStore
+}
+
+func (failingStore) SetComponentAccessToken(context.Context, string, *Token) error {
+	return errors.New("store is down")
+}
+
+func TestComponentTokenSource_Token_storeDown(t *testing.T) {
+	logger := new(bufferLogger)
+	ts, fetches := setupTokenSource(t, 7200, WithTokenStore(failingStore{NewMemoryTokenStore()}), WithLogger(logger))
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if token, err := ts.Token(ctx); err != nil || token != "token-1" {
+			t.Errorf("Token returned %v, %v, want token-1", token, err)
+		}
+	}
+	if n := *fetches; n != 1 {
+		t.Errorf("%d tokens fetched, want 1", n)
+	}
+	if !strings.Contains(logger.String(), "store is down") {
+		t.Errorf("log %q does not report the save failure", logger.String())
+	}
+}
@@ -0,0 +1,307 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotFound is returned by TokenStore when no token is stored.
+var ErrTokenNotFound = errors.New("token not found")
+
+// Token is a credential persisted in a TokenStore.
+type Token struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Valid reports whether t is still valid margin from now.
+func (t *Token) Valid(margin time.Duration) bool {
+	return t != nil && t.Value != "" && time.Now().Add(margin).Before(t.ExpiresAt)
+}
+
+// AuthorizerToken is the credential pair of an authorized micro app.
+// authorizer_refresh_token can be used only once, it must be persisted
+// as soon as it is rotated.
+type AuthorizerToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Valid reports whether the access token is still valid margin from now.
+func (t *AuthorizerToken) Valid(margin time.Duration) bool {
+	return t != nil && t.AccessToken != "" && time.Now().Add(margin).Before(t.ExpiresAt)
+}
+
+// TokenStore persists tickets and tokens, so that several processes can share them.
+// Get methods return ErrTokenNotFound if nothing is stored.
+type TokenStore interface {
+	GetComponentTicket(ctx context.Context, componentAppID string) (*Token, error)
+	SetComponentTicket(ctx context.Context, componentAppID string, ticket *Token) error
+
+	GetComponentAccessToken(ctx context.Context, componentAppID string) (*Token, error)
+	SetComponentAccessToken(ctx context.Context, componentAppID string, token *Token) error
+
+	GetAuthorizerToken(ctx context.Context, componentAppID, authorizerAppID string) (*AuthorizerToken, error)
+	SetAuthorizerToken(ctx context.Context, componentAppID, authorizerAppID string, token *AuthorizerToken) error
+}
+
+// tokenData is the content of a token store.
+type tokenData struct {
+	ComponentTickets      map[string]Token           `json:"component_tickets"`
+	ComponentAccessTokens map[string]Token           `json:"component_access_tokens"`
+	AuthorizerTokens      map[string]AuthorizerToken `json:"authorizer_tokens"`
+}
+
+func newTokenData() *tokenData {
+	return &tokenData{
+		ComponentTickets:      make(map[string]Token),
+		ComponentAccessTokens: make(map[string]Token),
+		AuthorizerTokens:      make(map[string]AuthorizerToken),
+	}
+}
+
+func authorizerKey(componentAppID, authorizerAppID string) string {
+	return componentAppID + "/" + authorizerAppID
+}
+
+func getToken(m map[string]Token, key string) (*Token, error) {
+	t, ok := m[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+func getAuthorizerToken(m map[string]AuthorizerToken, key string) (*AuthorizerToken, error) {
+	t, ok := m[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+// MemoryTokenStore is a TokenStore keeping tokens in memory.
+// It's safe for concurrent use but not shared across processes.
+type MemoryTokenStore struct {
+	mu   sync.RWMutex
+	data *tokenData
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{data: newTokenData()}
+}
+
+// GetComponentTicket implements TokenStore interface.
+func (s *MemoryTokenStore) GetComponentTicket(_ context.Context, componentAppID string) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return getToken(s.data.ComponentTickets, componentAppID)
+}
+
+// SetComponentTicket implements TokenStore interface.
+func (s *MemoryTokenStore) SetComponentTicket(_ context.Context, componentAppID string, ticket *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.ComponentTickets[componentAppID] = *ticket
+	return nil
+}
+
+// GetComponentAccessToken implements TokenStore interface.
+func (s *MemoryTokenStore) GetComponentAccessToken(_ context.Context, componentAppID string) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return getToken(s.data.ComponentAccessTokens, componentAppID)
+}
+
+// SetComponentAccessToken implements TokenStore interface.
+func (s *MemoryTokenStore) SetComponentAccessToken(_ context.Context, componentAppID string, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.ComponentAccessTokens[componentAppID] = *token
+	return nil
+}
+
+// GetAuthorizerToken implements TokenStore interface.
+func (s *MemoryTokenStore) GetAuthorizerToken(_ context.Context, componentAppID, authorizerAppID string) (
+	*AuthorizerToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return getAuthorizerToken(s.data.AuthorizerTokens, authorizerKey(componentAppID, authorizerAppID))
+}
+
+// SetAuthorizerToken implements TokenStore interface.
+func (s *MemoryTokenStore) SetAuthorizerToken(_ context.Context, componentAppID, authorizerAppID string,
+	token *AuthorizerToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.AuthorizerTokens[authorizerKey(componentAppID, authorizerAppID)] = *token
+	return nil
+}
+
+// FileTokenStore is a TokenStore keeping tokens in a JSON file.
+// Every write replaces the file atomically and is synced to disk before
+// returning, so a rotated refresh token survives a crash.
+//
+// It's for a single process only: writes are serialized within the process,
+// but processes sharing the file may overwrite each other's updates. Replicas
+// should share a TokenStore backed by a database instead.
+type FileTokenStore struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewFileTokenStore returns a FileTokenStore backed by the file at path.
+// The file is created on first write.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// GetComponentTicket implements TokenStore interface.
+func (s *FileTokenStore) GetComponentTicket(_ context.Context, componentAppID string) (*Token, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return getToken(data.ComponentTickets, componentAppID)
+}
+
+// SetComponentTicket implements TokenStore interface.
+func (s *FileTokenStore) SetComponentTicket(_ context.Context, componentAppID string, ticket *Token) error {
+	return s.update(func(data *tokenData) {
+		data.ComponentTickets[componentAppID] = *ticket
+	})
+}
+
+// GetComponentAccessToken implements TokenStore interface.
+func (s *FileTokenStore) GetComponentAccessToken(_ context.Context, componentAppID string) (*Token, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return getToken(data.ComponentAccessTokens, componentAppID)
+}
+
+// SetComponentAccessToken implements TokenStore interface.
+func (s *FileTokenStore) SetComponentAccessToken(_ context.Context, componentAppID string, token *Token) error {
+	return s.update(func(data *tokenData) {
+		data.ComponentAccessTokens[componentAppID] = *token
+	})
+}
+
+// GetAuthorizerToken implements TokenStore interface.
+func (s *FileTokenStore) GetAuthorizerToken(_ context.Context, componentAppID, authorizerAppID string) (
+	*AuthorizerToken, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return getAuthorizerToken(data.AuthorizerTokens, authorizerKey(componentAppID, authorizerAppID))
+}
+
+// SetAuthorizerToken implements TokenStore interface.
+func (s *FileTokenStore) SetAuthorizerToken(_ context.Context, componentAppID, authorizerAppID string,
+	token *AuthorizerToken) error {
+	return s.update(func(data *tokenData) {
+		data.AuthorizerTokens[authorizerKey(componentAppID, authorizerAppID)] = *token
+	})
+}
+
+func (s *FileTokenStore) read() (*tokenData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *FileTokenStore) update(fn func(data *tokenData)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	fn(data)
+	return s.save(data)
+}
+
+func (s *FileTokenStore) load() (*tokenData, error) {
+	data := newTokenData()
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return data, nil
+	}
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, err
+	}
+	if data.ComponentTickets == nil {
+		data.ComponentTickets = make(map[string]Token)
+	}
+	if data.ComponentAccessTokens == nil {
+		data.ComponentAccessTokens = make(map[string]Token)
+	}
+	if data.AuthorizerTokens == nil {
+		data.AuthorizerTokens = make(map[string]AuthorizerToken)
+	}
+	return data, nil
+}
+
+func (s *FileTokenStore) save(data *tokenData) error {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(s.path))
+}
+
+// syncDir syncs the directory dir, so that a file renamed into it is durable.
+func syncDir(dir string) error {
+	// directories can not be synced on windows, where renames are durable.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package bytedance
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// tempStorePath returns the path of a token file in a new temporary dir.
+func tempStorePath(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bytedance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "tokens.json")
+}
+
+func TestTokenStore_roundTrip(t *testing.T) {
+	stores := map[string]TokenStore{
+		"memory": NewMemoryTokenStore(),
+		"file":   NewFileTokenStore(tempStorePath(t)),
+	}
+	// times are compared after a JSON round trip.
+	expiresAt := time.Now().Add(time.Hour).Round(time.Second).UTC()
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.GetComponentTicket(ctx, "component"); err != ErrTokenNotFound {
+				t.Errorf("GetComponentTicket returned error %v, want ErrTokenNotFound", err)
+			}
+			if _, err := store.GetComponentAccessToken(ctx, "component"); err != ErrTokenNotFound {
+				t.Errorf("GetComponentAccessToken returned error %v, want ErrTokenNotFound", err)
+			}
+			if _, err := store.GetAuthorizerToken(ctx, "component", "app"); err != ErrTokenNotFound {
+				t.Errorf("GetAuthorizerToken returned error %v, want ErrTokenNotFound", err)
+			}
+
+			ticket := &Token{Value: "ticket", ExpiresAt: expiresAt}
+			token := &Token{Value: "token", ExpiresAt: expiresAt}
+			authorizer := &AuthorizerToken{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: expiresAt}
+			if err := store.SetComponentTicket(ctx, "component", ticket); err != nil {
+				t.Fatalf("SetComponentTicket returned error: %v", err)
+			}
+			if err := store.SetComponentAccessToken(ctx, "component", token); err != nil {
+				t.Fatalf("SetComponentAccessToken returned error: %v", err)
+			}
+			if err := store.SetAuthorizerToken(ctx, "component", "app", authorizer); err != nil {
+				t.Fatalf("SetAuthorizerToken returned error: %v", err)
+			}
+
+			if got, err := store.GetComponentTicket(ctx, "component"); err != nil || !reflect.DeepEqual(got, ticket) {
+				t.Errorf("GetComponentTicket returned %+v, %v, want %+v", got, err, ticket)
+			}
+			if got, err := store.GetComponentAccessToken(ctx, "component"); err != nil || !reflect.DeepEqual(got, token) {
+				t.Errorf("GetComponentAccessToken returned %+v, %v, want %+v", got, err, token)
+			}
+			got, err := store.GetAuthorizerToken(ctx, "component", "app")
+			if err != nil || !reflect.DeepEqual(got, authorizer) {
+				t.Errorf("GetAuthorizerToken returned %+v, %v, want %+v", got, err, authorizer)
+			}
+			// tokens are keyed by component and authorizer appid.
+			if _, err := store.GetAuthorizerToken(ctx, "other", "app"); err != ErrTokenNotFound {
+				t.Errorf("GetAuthorizerToken of other component returned error %v, want ErrTokenNotFound", err)
+			}
+
+			// returned tokens are copies.
+			got.AccessToken = "changed"
+			if again, _ := store.GetAuthorizerToken(ctx, "component", "app"); again.AccessToken != "access" {
+				t.Errorf("GetAuthorizerToken returned %v after the returned token changed", again.AccessToken)
+			}
+		})
+	}
+}
+
+func TestFileTokenStore_persisted(t *testing.T) {
+	path := tempStorePath(t)
+	ctx := context.Background()
+	token := &AuthorizerToken{AccessToken: "access", RefreshToken: "refresh"}
+	if err := NewFileTokenStore(path).SetAuthorizerToken(ctx, "component", "app", token); err != nil {
+		t.Fatalf("SetAuthorizerToken returned error: %v", err)
+	}
+
+	// another store of the file, e.g. after a restart, reads it.
+	got, err := NewFileTokenStore(path).GetAuthorizerToken(ctx, "component", "app")
+	if err != nil || got.RefreshToken != "refresh" {
+		t.Errorf("GetAuthorizerToken returned %+v, %v, want refresh token refresh", got, err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode is %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestFileTokenStore_atomicReplace(t *testing.T) {
+	path := tempStorePath(t)
+	store := NewFileTokenStore(path)
+	ctx := context.Background()
+	if err := store.SetComponentAccessToken(ctx, "component", &Token{Value: "old"}); err != nil {
+		t.Fatalf("SetComponentAccessToken returned error: %v", err)
+	}
+	old, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+
+	if err := store.SetComponentAccessToken(ctx, "component", &Token{Value: "new"}); err != nil {
+		t.Fatalf("SetComponentAccessToken returned error: %v", err)
+	}
+	// the file is replaced rather than rewritten, readers see either version in full.
+	if runtime.GOOS != "windows" {
+		b, err := ioutil.ReadAll(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !containsToken(b, "old") {
+			t.Errorf("file opened before the write has %s, want the old version", b)
+		}
+	}
+	if got, _ := store.GetComponentAccessToken(ctx, "component"); got.Value != "new" {
+		t.Errorf("GetComponentAccessToken returned %v, want new", got.Value)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("dir has %d files, want no temporary files left", len(files))
+	}
+}
+
+func TestFileTokenStore_invalidFile(t *testing.T) {
+	path := tempStorePath(t)
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileTokenStore(path)
+	ctx := context.Background()
+	if _, err := store.GetComponentTicket(ctx, "component"); err == nil || err == ErrTokenNotFound {
+		t.Errorf("GetComponentTicket of invalid file returned error %v", err)
+	}
+	// a write does not replace a file which can not be read.
+	if err := store.SetComponentTicket(ctx, "component", &Token{Value: "ticket"}); err == nil {
+		t.Error("SetComponentTicket of invalid file returned no error")
+	}
+	if b := mustReadFile(t, path); string(b) != "{" {
+		t.Errorf("invalid file is changed to %s", b)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func containsToken(b []byte, value string) bool {
+	data := newTokenData()
+	return json.Unmarshal(b, data) == nil && data.ComponentAccessTokens["component"].Value == value
+}
@@ -0,0 +1,51 @@
+package bytedance
+
+import (
+	"context"
+)
+
+// HeaderLogID is the response header carrying the server log ID of a request,
+// which ByteDance support asks for when troubleshooting.
+const HeaderLogID = "X-Tt-Logid"
+
+// Tracer starts a span for every API call of a Client, it can be adapted to
+// OpenTelemetry or any other tracing system.
+type Tracer interface {
+	// StartSpan starts a span of the call to endpoint, e.g. "microapp.package.upload".
+	// The returned context is used to send the requests of the call.
+	StartSpan(ctx context.Context, endpoint string) (context.Context, Span)
+}
+
+// Span is an API call being traced.
+type Span interface {
+	// End finishes the span with the result of the call.
+	End(info SpanInfo)
+}
+
+// SpanInfo is the result of a traced API call.
+type SpanInfo struct {
+	// Endpoint is the logical name of the endpoint, e.g. "microapp.package.upload".
+	Endpoint string
+
+	// StatusCode is the HTTP status of the last response, zero if there is none.
+	StatusCode int
+
+	// ErrNo is the errno of the last response.
+	ErrNo int
+
+	// Retries is the number of attempts after the first one.
+	Retries int
+
+	// LogID is the server log ID of the last response.
+	LogID string
+
+	// Err is the error of the call, if any.
+	Err error
+}
+
+// WithTracer sets the Tracer of the client.
+func WithTracer(tracer Tracer) Option {
+	return func(c *Client) {
+		c.Tracer = tracer
+	}
+}
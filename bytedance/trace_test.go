@@ -0,0 +1,105 @@
+package bytedance_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-bytedance/bytedance"
+	"github.com/Cluas/go-bytedance/bytedance/bytedancetest"
+)
+
+type spanKey struct{}
+
+// recordingTracer records the info of every ended span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	ended []bytedance.SpanInfo
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, endpoint string) (context.Context, bytedance.Span) {
+	return context.WithValue(ctx, spanKey{}, endpoint), t
+}
+
+func (t *recordingTracer) End(info bytedance.SpanInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ended = append(t.ended, info)
+}
+
+func (t *recordingTracer) spans() []bytedance.SpanInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]bytedance.SpanInfo(nil), t.ended...)
+}
+
+func TestTracer(t *testing.T) {
+	s := bytedancetest.NewServer()
+	defer s.Close()
+	ctx := context.Background()
+	componentToken, err := bytedance.NewComponentTokenSource(s.Client(), bytedancetest.ComponentAppID,
+		bytedancetest.ComponentAppSecret, bytedance.StaticTicket(s.Ticket())).Token(ctx)
+	if err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+
+	tracer := new(recordingTracer)
+	var spanCtx []interface{}
+	c := s.Client(
+		bytedance.WithTracer(tracer),
+		bytedance.WithRetryPolicy(&bytedance.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		bytedance.WithInterceptors(func(ctx context.Context, call *bytedance.Call, next bytedance.Invoker) error {
+			spanCtx = append(spanCtx, call.Request.Context().Value(spanKey{}))
+			return next(ctx, call)
+		}),
+	)
+
+	// retried once after the server is busy.
+	s.InjectErrNo("tp.template.get_tpl_list", -1, "busy", 1)
+	_, resp, err := c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, componentToken)
+	if err != nil {
+		t.Fatalf("GetTemplates returned error: %v", err)
+	}
+	spans := tracer.spans()
+	if len(spans) != 1 {
+		t.Fatalf("tracer ended %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	want := bytedance.SpanInfo{
+		Endpoint:   "tp.template.get_tpl_list",
+		StatusCode: http.StatusOK,
+		Retries:    1,
+		LogID:      resp.LogID,
+	}
+	if got != want {
+		t.Errorf("span ended with %+v, want %+v", got, want)
+	}
+	if !strings.HasPrefix(got.LogID, "log") {
+		t.Errorf("span ended with log id %q of the server", got.LogID)
+	}
+	for _, v := range spanCtx {
+		if v != "tp.template.get_tpl_list" {
+			t.Errorf("request is sent with context of span %v", v)
+		}
+	}
+	if len(spanCtx) != 2 {
+		t.Errorf("interceptors saw %d attempts, want 2", len(spanCtx))
+	}
+
+	// an API error ends the span with its errno.
+	s.InjectErrNo("tp.template.get_tpl_list", bytedancetest.ErrNoPermission, "denied", 1)
+	_, _, err = c.ThirdParty.GetTemplates(ctx, bytedancetest.ComponentAppID, componentToken)
+	spans = tracer.spans()
+	if len(spans) != 2 {
+		t.Fatalf("tracer ended %d spans, want 2", len(spans))
+	}
+	got = spans[1]
+	if got.ErrNo != bytedancetest.ErrNoPermission || got.Retries != 0 || got.LogID == "" ||
+		got.LogID == spans[0].LogID || !errors.Is(got.Err, bytedance.ErrPermissionDenied) || got.Err != err {
+		t.Errorf("span ended with %+v, want errno %d of error %v", got, bytedancetest.ErrNoPermission, err)
+	}
+}
@@ -0,0 +1,184 @@
+package bytedance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxIntroLength is the max length of the intro of a micro app in characters.
+const maxIntroLength = 120
+
+// Validator is implemented by request bodies checked before they are sent,
+// see Client.SkipValidation.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is an invalid field of a request body. It matches
+// ErrInvalidParameter with errors.Is, like the errno returned by the server.
+type FieldError struct {
+	Field   string // Go name of the field, e.g. "Version"
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %v: %v", e.Field, e.Message)
+}
+
+// Is reports whether target is ErrInvalidParameter.
+func (e *FieldError) Is(target error) bool {
+	return target == ErrInvalidParameter
+}
+
+// ValidationErrors is the list of invalid fields of a request body.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrInvalidParameter.
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidParameter
+}
+
+// add appends a FieldError of field.
+func (e *ValidationErrors) add(field, format string, v ...interface{}) {
+	*e = append(*e, &FieldError{Field: field, Message: fmt.Sprintf(format, v...)})
+}
+
+// required appends a FieldError of field if value is empty.
+func (e *ValidationErrors) required(field, value string) {
+	if value == "" {
+		e.add(field, "is required")
+	}
+}
+
+// oneOf appends a FieldError of field if value is not one of values.
+func (e *ValidationErrors) oneOf(field, value string, values ...string) {
+	for _, v := range values {
+		if value == v {
+			return
+		}
+	}
+	e.add(field, "%q is not one of %v", value, strings.Join(values, ", "))
+}
+
+// err returns e, or nil if there is no FieldError.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate implements Validator interface.
+func (r *UploadPicMaterialRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if r.MaterialFile == nil || r.MaterialFile.Content == nil {
+		errs.add("MaterialFile", "is required")
+	}
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *DownloadQrcodeRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.oneOf("Version", r.Version, "current", "audit", "latest")
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *ModifyAppNameRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.required("NewName", r.NewName)
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *ModifyAppIntroRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.required("NewIntro", r.NewIntro)
+	if n := utf8.RuneCountInString(r.NewIntro); n > maxIntroLength {
+		errs.add("NewIntro", "is %d characters, longer than %d", n, maxIntroLength)
+	}
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *ModifyAppIconRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.required("NewIconPath", r.NewIconPath)
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *ModifyServerDomainRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.oneOf("Action", r.Action, "add", "delete", "set", "get")
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *ModifyWebviewDomainRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.oneOf("Action", r.Action, "add", "delete", "set", "get")
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *UploadPackageRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	errs.required("UserVersion", r.UserVersion)
+	if r.ExtJSON != "" && !json.Valid([]byte(r.ExtJSON)) {
+		errs.add("ExtJSON", "is not valid JSON")
+	}
+	return errs.err()
+}
+
+// Validate implements Validator interface.
+func (r *CommitAuditPackageRequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if len(r.HostNames) == 0 {
+		errs.add("HostNames", "is required")
+	}
+	for i, name := range r.HostNames {
+		if name == "" {
+			errs.add(fmt.Sprintf("HostNames[%d]", i), "is empty")
+		}
+	}
+	return errs.err()
+}
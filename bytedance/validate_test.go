@@ -0,0 +1,88 @@
+package bytedance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       Validator
+		wantFields []string
+	}{
+		{"nil body", (*ModifyAppNameRequest)(nil), nil},
+		{"pic material", &UploadPicMaterialRequest{MaterialFile: &File{Content: strings.NewReader("png")}}, nil},
+		{"pic material without file", &UploadPicMaterialRequest{}, []string{"MaterialFile"}},
+		{"pic material without content", &UploadPicMaterialRequest{MaterialFile: &File{Name: "a.png"}},
+			[]string{"MaterialFile"}},
+		{"qrcode", &DownloadQrcodeRequest{Version: "audit"}, nil},
+		{"qrcode with unknown version", &DownloadQrcodeRequest{Version: "beta"}, []string{"Version"}},
+		{"app name", &ModifyAppNameRequest{NewName: "字节小程序"}, nil},
+		{"empty app name", &ModifyAppNameRequest{}, []string{"NewName"}},
+		{"app intro of 120 characters", &ModifyAppIntroRequest{NewIntro: strings.Repeat("字", 120)}, nil},
+		{"app intro of 121 characters", &ModifyAppIntroRequest{NewIntro: strings.Repeat("字", 121)},
+			[]string{"NewIntro"}},
+		{"empty app intro", &ModifyAppIntroRequest{}, []string{"NewIntro"}},
+		{"app icon", &ModifyAppIconRequest{NewIconPath: "path"}, nil},
+		{"empty app icon", &ModifyAppIconRequest{}, []string{"NewIconPath"}},
+		{"server domain", &ModifyServerDomainRequest{Action: "get"}, nil},
+		{"server domain with unknown action", &ModifyServerDomainRequest{Action: "replace"}, []string{"Action"}},
+		{"webview domain", &ModifyWebviewDomainRequest{Action: "set"}, nil},
+		{"webview domain without action", &ModifyWebviewDomainRequest{}, []string{"Action"}},
+		{"package", &UploadPackageRequest{UserVersion: "1.0.0", ExtJSON: `{"extEnable":true}`}, nil},
+		{"package with invalid ext json", &UploadPackageRequest{ExtJSON: "{"}, []string{"UserVersion", "ExtJSON"}},
+		{"audit", &CommitAuditPackageRequest{HostNames: []string{"toutiao"}}, nil},
+		{"audit without hosts", &CommitAuditPackageRequest{}, []string{"HostNames"}},
+		{"audit with empty host", &CommitAuditPackageRequest{HostNames: []string{"toutiao", ""}},
+			[]string{"HostNames[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.body.Validate()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Errorf("Validate returned error: %v", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate returned error %v, want ValidationErrors", err)
+			}
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("Validate returned error %v, want it to match ErrInvalidParameter", err)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("Validate returned invalid fields %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewRequest_validates(t *testing.T) {
+	c, queries := setup(t)
+	_, err := c.MicroApp.ModifyAppName(context.Background(), "appid", "token", &ModifyAppNameRequest{})
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("ModifyAppName returned error %v, want ErrInvalidParameter", err)
+	}
+	if len(*queries) != 0 {
+		t.Errorf("server received %d requests, want none", len(*queries))
+	}
+
+	c.SkipValidation = true
+	if _, err := c.NewRequest(http.MethodPost, "v1/test", &ModifyAppNameRequest{}); err != nil {
+		t.Errorf("NewRequest with SkipValidation returned error: %v", err)
+	}
+	c = NewClient(WithoutValidation())
+	if _, err := c.NewRequest(http.MethodPost, "v1/test", &ModifyAppNameRequest{}); err != nil {
+		t.Errorf("NewRequest of client WithoutValidation returned error: %v", err)
+	}
+}
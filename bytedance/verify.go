@@ -2,6 +2,7 @@ package bytedance
 
 import (
 	"crypto/sha1"
+	"crypto/subtle"
 	"fmt"
 	"sort"
 	"strings"
@@ -9,10 +10,15 @@ import (
 
 // Verify 校验加密信息
 func Verify(tpToken string, timestamp string, nonce string, encrypt string, msgSignature string) bool {
+	signature := Signature(tpToken, timestamp, nonce, encrypt)
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(msgSignature)) == 1
+}
+
+// Signature 生成加密信息的签名 msg_signature
+func Signature(tpToken string, timestamp string, nonce string, encrypt string) string {
 	values := []string{tpToken, timestamp, nonce, encrypt}
 	sort.Strings(values)
-	newMsgSignature := Sha1(strings.Join(values, ""))
-	return newMsgSignature == msgSignature
+	return Sha1(strings.Join(values, ""))
 }
 
 // Sha1 sha1 加密